@@ -0,0 +1,205 @@
+package cachedebug
+
+import (
+	"fmt"
+	"github.com/go-playground/cache/internal/stats"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Cache is the set of functions required to inspect and manage a cache, satisfied by wrapping an lru or lfu
+// ThreadSafeCache using LRU or LFU.
+type Cache interface {
+	// Policy returns the name of the caches eviction policy, such as lru.
+	Policy() string
+
+	// Stats returns the caches Stats.
+	Stats() any
+
+	// MaxAge returns the caches MaxAge, zero being no max age.
+	MaxAge() time.Duration
+
+	// Keys returns up to limit entries, after skipping offset entries, in the order the cache keeps them along with if
+	// there are more.
+	Keys(offset, limit int) (keys []Key, more bool)
+
+	// Evict removes the entry whose key is formatted as returned by Keys, returning an error if it cannot be parsed.
+	Evict(key string) error
+
+	// Clear empties the cache.
+	Clear()
+}
+
+// Key is an entry listed by Keys.
+type Key struct {
+	// Key is the formatted key.
+	Key string
+
+	// Age is the duration since the value was set.
+	Age time.Duration
+
+	// TTL is the remaining duration before the entry expires or zero if it has no max age.
+	TTL time.Duration
+
+	// Frequency is the frequency tier the entry belongs to, zero if the cache does not track frequency.
+	Frequency int
+}
+
+type lruCache[K comparable, V any] struct {
+	cache  lru.ThreadSafeCache[K, V]
+	totals stats.Totals[lru.Stats]
+}
+
+// LRU wraps an lru ThreadSafeCache as a Cache listing keys in recency order, most recently used first.
+//
+// The Stats deltas returned by the cache are accumulated into totals each time the cache is viewed and so nothing
+// else should call the caches Stats function.
+func LRU[K comparable, V any](cache lru.ThreadSafeCache[K, V]) Cache {
+	return &lruCache[K, V]{cache: cache}
+}
+
+func (c *lruCache[K, V]) Policy() string {
+	return "lru"
+}
+
+func (c *lruCache[K, V]) Stats() any {
+	return c.totals.Sum(c.cache.Stats())
+}
+
+func (c *lruCache[K, V]) MaxAge() time.Duration {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	return guard.T.MaxAge()
+}
+
+func (c *lruCache[K, V]) Keys(offset, limit int) ([]Key, bool) {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	return page(guard.T.Range, offset, limit, func(key K, e lru.Entry[V]) Key {
+		return Key{Key: fmt.Sprint(key), Age: e.Age, TTL: e.TTL}
+	})
+}
+
+func (c *lruCache[K, V]) Evict(key string) error {
+	k, err := parseKey[K](key)
+	if err != nil {
+		return err
+	}
+	c.cache.Remove(k)
+	return nil
+}
+
+func (c *lruCache[K, V]) Clear() {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	// accumulate the Stats delta first as clearing resets it.
+	c.totals.Sum(guard.T.Stats())
+	guard.T.Clear()
+}
+
+type lfuCache[K comparable, V any] struct {
+	cache  lfu.ThreadSafeCache[K, V]
+	totals stats.Totals[lfu.Stats]
+}
+
+// LFU wraps an lfu ThreadSafeCache as a Cache listing keys by frequency tier, most frequently used first, along with
+// their frequency.
+//
+// The Stats deltas returned by the cache are accumulated into totals each time the cache is viewed and so nothing
+// else should call the caches Stats function.
+func LFU[K comparable, V any](cache lfu.ThreadSafeCache[K, V]) Cache {
+	return &lfuCache[K, V]{cache: cache}
+}
+
+func (c *lfuCache[K, V]) Policy() string {
+	return "lfu"
+}
+
+func (c *lfuCache[K, V]) Stats() any {
+	return c.totals.Sum(c.cache.Stats())
+}
+
+func (c *lfuCache[K, V]) MaxAge() time.Duration {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	return guard.T.MaxAge()
+}
+
+func (c *lfuCache[K, V]) Keys(offset, limit int) ([]Key, bool) {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	return page(guard.T.Range, offset, limit, func(key K, e lfu.Entry[V]) Key {
+		return Key{Key: fmt.Sprint(key), Age: e.Age, TTL: e.TTL, Frequency: e.Frequency}
+	})
+}
+
+func (c *lfuCache[K, V]) Evict(key string) error {
+	k, err := parseKey[K](key)
+	if err != nil {
+		return err
+	}
+	c.cache.Remove(k)
+	return nil
+}
+
+func (c *lfuCache[K, V]) Clear() {
+	guard := c.cache.LockGuard()
+	defer guard.Unlock()
+	// accumulate the Stats delta first as clearing resets it.
+	c.totals.Sum(guard.T.Stats())
+	guard.T.Clear()
+}
+
+// page collects up to limit keys after skipping offset entries using the caches Range function.
+func page[K comparable, E any](rangeFn func(func(K, E) bool), offset, limit int, fn func(K, E) Key) (keys []Key, more bool) {
+	var i int
+	rangeFn(func(key K, e E) bool {
+		switch {
+		case i < offset:
+		case len(keys) == limit:
+			more = true
+			return false
+		default:
+			keys = append(keys, fn(key, e))
+		}
+		i++
+		return true
+	})
+	return
+}
+
+// parseKey parses a key formatted using fmt.Sprint back into its type, supporting keys whose underlying type is a
+// string, integer, float or bool.
+func parseKey[K comparable](s string) (key K, err error) {
+	v := reflect.ValueOf(&key).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	default:
+		err = fmt.Errorf("cachedebug: unsupported key type %s", v.Type())
+	}
+	return
+}
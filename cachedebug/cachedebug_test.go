@@ -0,0 +1,227 @@
+package cachedebug
+
+import (
+	"encoding/json"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func do(h http.Handler, method, target string, form url.Values) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form != nil {
+		r = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestBadConfig(t *testing.T) {
+	PanicMatches(t, func() { New().PageSize(0) }, "PageSize must be a positive value")
+	PanicMatches(t, func() {
+		New().Register("", LRU(lru.New[string, int](1).BuildThreadSafe()))
+	}, "Register name must not be empty")
+	PanicMatches(t, func() {
+		c := LRU(lru.New[string, int](1).BuildThreadSafe())
+		New().Register("a", c).Register("a", c)
+	}, "Register name must be unique, a is already registered")
+}
+
+func TestHandlerJSON(t *testing.T) {
+	users := lru.New[string, int](10).MaxAge(time.Hour).BuildThreadSafe()
+	sessions := lfu.New[int, string](10).BuildThreadSafe()
+	h := New().PageSize(2).Register("users", LRU(users)).Register("sessions", LFU(sessions)).Build()
+
+	users.Set("a", 1)
+	users.Set("b", 2)
+	users.Set("c", 3)
+	users.Get("a")
+	users.Get("z")
+	sessions.Set(1, "one")
+	sessions.Set(2, "two")
+	sessions.Get(2)
+
+	w := do(h, http.MethodGet, "/?format=json", nil)
+	Equal(t, w.Code, http.StatusOK)
+	Equal(t, w.Header().Get("Content-Type"), "application/json")
+	var summaries []struct {
+		Name   string         `json:"name"`
+		Policy string         `json:"policy"`
+		MaxAge string         `json:"max_age"`
+		Stats  map[string]int `json:"stats"`
+	}
+	Equal(t, json.Unmarshal(w.Body.Bytes(), &summaries), nil)
+	Equal(t, len(summaries), 2)
+	Equal(t, summaries[0].Name, "users")
+	Equal(t, summaries[0].Policy, "lru")
+	Equal(t, summaries[0].MaxAge, "1h0m0s")
+	Equal(t, summaries[0].Stats["capacity"], 10)
+	Equal(t, summaries[0].Stats["len"], 3)
+	Equal(t, summaries[0].Stats["hits"], 1)
+	Equal(t, summaries[0].Stats["misses"], 1)
+	Equal(t, summaries[1].Name, "sessions")
+	Equal(t, summaries[1].Policy, "lfu")
+	Equal(t, summaries[1].MaxAge, "")
+
+	type detail struct {
+		Stats map[string]int `json:"stats"`
+		Keys  []struct {
+			Key       string `json:"key"`
+			TTL       string `json:"ttl"`
+			Frequency int    `json:"frequency"`
+		} `json:"keys"`
+		More bool `json:"more"`
+	}
+
+	// stats are totals rather than deltas and keys are listed in recency order.
+	var d detail
+	r := httptest.NewRequest(http.MethodGet, "/?name=users", nil)
+	r.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	Equal(t, w.Code, http.StatusOK)
+	Equal(t, json.Unmarshal(w.Body.Bytes(), &d), nil)
+	Equal(t, d.Stats["hits"], 1)
+	Equal(t, len(d.Keys), 2)
+	Equal(t, d.Keys[0].Key, "a")
+	Equal(t, d.Keys[1].Key, "c")
+	Equal(t, d.Keys[0].TTL != "", true)
+	Equal(t, d.More, true)
+
+	d = detail{}
+	w = do(h, http.MethodGet, "/?name=users&format=json&offset=2", nil)
+	Equal(t, json.Unmarshal(w.Body.Bytes(), &d), nil)
+	Equal(t, len(d.Keys), 1)
+	Equal(t, d.Keys[0].Key, "b")
+	Equal(t, d.More, false)
+
+	// LFU keys are listed by frequency tier with their frequency.
+	d = detail{}
+	w = do(h, http.MethodGet, "/?name=sessions&format=json&limit=10", nil)
+	Equal(t, json.Unmarshal(w.Body.Bytes(), &d), nil)
+	Equal(t, len(d.Keys), 2)
+	Equal(t, d.Keys[0].Key, "2")
+	Equal(t, d.Keys[0].Frequency, 2)
+	Equal(t, d.Keys[1].Key, "1")
+	Equal(t, d.Keys[1].Frequency, 1)
+
+	w = do(h, http.MethodPost, "/?name=sessions&format=json", url.Values{"action": {"evict"}, "key": {"2"}})
+	Equal(t, w.Code, http.StatusNoContent)
+	Equal(t, sessions.Get(2), optionext.None[string]())
+	Equal(t, sessions.Get(1), optionext.Some("one"))
+
+	w = do(h, http.MethodPost, "/?name=sessions&format=json", url.Values{"action": {"evict"}, "key": {"x"}})
+	Equal(t, w.Code, http.StatusBadRequest)
+
+	w = do(h, http.MethodPost, "/?name=users", url.Values{"action": {"clear"}})
+	Equal(t, w.Code, http.StatusSeeOther)
+	Equal(t, w.Header().Get("Location"), "?name=users")
+	Equal(t, users.Get("a"), optionext.None[int]())
+}
+
+func TestHandlerClearStats(t *testing.T) {
+	users := lru.New[string, int](10).BuildThreadSafe()
+	sessions := lfu.New[int, string](10).BuildThreadSafe()
+	h := New().Register("users", LRU(users)).Register("sessions", LFU(sessions)).Build()
+
+	// counts since the cache was last viewed survive clearing resetting the caches Stats.
+	users.Set("a", 1)
+	users.Get("a")
+	sessions.Set(1, "one")
+	sessions.Get(2)
+	Equal(t, do(h, http.MethodPost, "/?name=users", url.Values{"action": {"clear"}}).Code, http.StatusSeeOther)
+	Equal(t, do(h, http.MethodPost, "/?name=sessions", url.Values{"action": {"clear"}}).Code, http.StatusSeeOther)
+
+	var summaries []struct {
+		Stats map[string]int `json:"stats"`
+	}
+	w := do(h, http.MethodGet, "/?format=json", nil)
+	Equal(t, json.Unmarshal(w.Body.Bytes(), &summaries), nil)
+	Equal(t, len(summaries), 2)
+	Equal(t, summaries[0].Stats["len"], 0)
+	Equal(t, summaries[0].Stats["sets"], 1)
+	Equal(t, summaries[0].Stats["hits"], 1)
+	Equal(t, summaries[1].Stats["sets"], 1)
+	Equal(t, summaries[1].Stats["misses"], 1)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := New().Register("users", LRU(lru.New[string, int](10).BuildThreadSafe())).Build()
+
+	Equal(t, do(h, http.MethodGet, "/?name=missing", nil).Code, http.StatusNotFound)
+	Equal(t, do(h, http.MethodGet, "/?name=users&offset=-1", nil).Code, http.StatusBadRequest)
+	Equal(t, do(h, http.MethodGet, "/?name=users&limit=0", nil).Code, http.StatusBadRequest)
+	Equal(t, do(h, http.MethodPost, "/?name=users", url.Values{"action": {"drop"}}).Code, http.StatusBadRequest)
+
+	w := do(h, http.MethodPost, "/", nil)
+	Equal(t, w.Code, http.StatusMethodNotAllowed)
+	Equal(t, w.Header().Get("Allow"), "GET, HEAD")
+	w = do(h, http.MethodDelete, "/?name=users", nil)
+	Equal(t, w.Code, http.StatusMethodNotAllowed)
+}
+
+func TestHandlerHTML(t *testing.T) {
+	c := lfu.New[string, int](10).BuildThreadSafe()
+	h := New().PageSize(1).Register("a<b", LFU(c)).Build()
+	c.Set("<script>", 1)
+	c.Set("2", 2)
+
+	w := do(h, http.MethodGet, "/", nil)
+	Equal(t, w.Code, http.StatusOK)
+	Equal(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	Equal(t, strings.Contains(w.Body.String(), `href="?name=a%3cb"`), true)
+	Equal(t, strings.Contains(w.Body.String(), "a&lt;b"), true)
+
+	w = do(h, http.MethodGet, "/?name="+url.QueryEscape("a<b"), nil)
+	Equal(t, w.Code, http.StatusOK)
+	body := w.Body.String()
+	Equal(t, strings.Contains(body, "<th>Frequency</th>"), true)
+	Equal(t, strings.Contains(body, "&lt;script&gt;"), false) // on the second page
+	Equal(t, strings.Contains(body, `value="2"`), true)
+	Equal(t, strings.Contains(body, "offset=1&amp;limit=1"), true)
+
+	w = do(h, http.MethodGet, "/?name="+url.QueryEscape("a<b")+"&offset=1", nil)
+	Equal(t, strings.Contains(w.Body.String(), "&lt;script&gt;"), true)
+	Equal(t, strings.Contains(w.Body.String(), "<script>"), false)
+}
+
+func TestParseKey(t *testing.T) {
+	type id uint16
+
+	s, err := parseKey[string]("a b")
+	Equal(t, err, nil)
+	Equal(t, s, "a b")
+
+	i, err := parseKey[int8]("-12")
+	Equal(t, err, nil)
+	Equal(t, i, int8(-12))
+
+	_, err = parseKey[int8]("300")
+	NotEqual(t, err, nil)
+
+	u, err := parseKey[id]("7")
+	Equal(t, err, nil)
+	Equal(t, u, id(7))
+
+	f, err := parseKey[float64]("1.5")
+	Equal(t, err, nil)
+	Equal(t, f, 1.5)
+
+	b, err := parseKey[bool]("true")
+	Equal(t, err, nil)
+	Equal(t, b, true)
+
+	_, err = parseKey[[2]int]("[1 2]")
+	Equal(t, err.Error(), "cachedebug: unsupported key type [2]int")
+}
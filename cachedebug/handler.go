@@ -0,0 +1,256 @@
+package cachedebug
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-playground/cache/internal/stats"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type builder struct {
+	handler *Handler
+}
+
+// New initializes a builder to create a Handler exposing the state of registered caches.
+func New() *builder {
+	return &builder{
+		handler: &Handler{
+			caches:   make(map[string]Cache),
+			pageSize: 100,
+		},
+	}
+}
+
+// PageSize sets the number of keys listed per page when the request does not specify a limit.
+//
+// Default is 100.
+func (b *builder) PageSize(size int) *builder {
+	if size <= 0 {
+		panic("PageSize must be a positive value")
+	}
+	b.handler.pageSize = size
+	return b
+}
+
+// Register adds a cache to be exposed under the provided name.
+func (b *builder) Register(name string, cache Cache) *builder {
+	if name == "" {
+		panic("Register name must not be empty")
+	}
+	if _, found := b.handler.caches[name]; found {
+		panic("Register name must be unique, " + name + " is already registered")
+	}
+	b.handler.names = append(b.handler.names, name)
+	b.handler.caches[name] = cache
+	return b
+}
+
+// Build finalizes configuration and returns the Handler for use.
+func (b *builder) Build() (handler *Handler) {
+	handler = b.handler
+	b.handler = nil
+	return
+}
+
+// Handler is an http.Handler exposing the Stats, MaxAge and keys of registered caches, along with evicting a key or
+// clearing a cache, rendered as HTML or as JSON when requested using the format=json query parameter or an Accept
+// header of application/json.
+//
+// It only uses query parameters and relative links and so may be mounted at any path, such as /debug/cache:
+//
+//   - GET lists the registered caches.
+//   - GET ?name=<name>&offset=<n>&limit=<n> shows a cache and a page of its keys.
+//   - POST ?name=<name> with action=evict&key=<key> removes the key or action=clear clears the cache.
+//
+// The Handler performs no authentication and so should only be reachable by operators.
+type Handler struct {
+	names    []string
+	caches   map[string]Cache
+	pageSize int
+}
+
+// ServeHTTP serves the request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			methodNotAllowed(w, http.MethodGet, http.MethodHead)
+			return
+		}
+		h.index(w, r)
+		return
+	}
+	cache, found := h.caches[name]
+	if !found {
+		http.Error(w, "cache "+strconv.Quote(name)+" is not registered", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.view(w, r, name, cache)
+	case http.MethodPost:
+		h.action(w, r, name, cache)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodHead, http.MethodPost)
+	}
+}
+
+type summary struct {
+	Name   string         `json:"name"`
+	Policy string         `json:"policy"`
+	MaxAge string         `json:"max_age,omitempty"`
+	Stats  map[string]any `json:"stats"`
+	Fields []stats.Field  `json:"-"`
+}
+
+type key struct {
+	Key       string `json:"key"`
+	Age       string `json:"age"`
+	TTL       string `json:"ttl,omitempty"`
+	Frequency int    `json:"frequency,omitempty"`
+}
+
+type detail struct {
+	summary
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+	Keys   []key `json:"keys"`
+	More   bool  `json:"more"`
+}
+
+func (h *Handler) summarize(name string, cache Cache) summary {
+	s := summary{
+		Name:   name,
+		Policy: cache.Policy(),
+		MaxAge: formatDuration(cache.MaxAge()),
+		Stats:  make(map[string]any),
+		Fields: stats.Fields(cache.Stats()),
+	}
+	for _, f := range s.Fields {
+		if _, err := strconv.ParseFloat(f.Value, 64); err == nil {
+			s.Stats[stats.SnakeCase(f.Name)] = json.Number(f.Value)
+		} else {
+			s.Stats[stats.SnakeCase(f.Name)] = f.Value
+		}
+	}
+	return s
+}
+
+func (h *Handler) index(w http.ResponseWriter, r *http.Request) {
+	summaries := make([]summary, 0, len(h.names))
+	for _, name := range h.names {
+		summaries = append(summaries, h.summarize(name, h.caches[name]))
+	}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, summaries)
+		return
+	}
+	writeHTML(w, indexTemplate, summaries)
+}
+
+func (h *Handler) view(w http.ResponseWriter, r *http.Request, name string, cache Cache) {
+	query := r.URL.Query()
+	offset, err := intParam(query, "offset", 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := intParam(query, "limit", h.pageSize, 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d := detail{
+		summary: h.summarize(name, cache),
+		Offset:  offset,
+		Limit:   limit,
+		Keys:    []key{},
+	}
+	var keys []Key
+	keys, d.More = cache.Keys(offset, limit)
+	for _, k := range keys {
+		d.Keys = append(d.Keys, key{
+			Key:       k.Key,
+			Age:       formatDuration(k.Age),
+			TTL:       formatDuration(k.TTL),
+			Frequency: k.Frequency,
+		})
+	}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, d)
+		return
+	}
+	writeHTML(w, detailTemplate, d)
+}
+
+func (h *Handler) action(w http.ResponseWriter, r *http.Request, name string, cache Cache) {
+	switch r.PostFormValue("action") {
+	case "evict":
+		if err := cache.Evict(r.PostFormValue("key")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "clear":
+		cache.Clear()
+	default:
+		http.Error(w, "action must be evict or clear", http.StatusBadRequest)
+		return
+	}
+	if wantsJSON(r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// relative to the current path so it works wherever the Handler is mounted.
+	w.Header().Set("Location", "?name="+url.QueryEscape(name))
+	w.WriteHeader(http.StatusSeeOther)
+}
+
+// intParam parses an optional integer query parameter which must be at least min.
+func intParam(query url.Values, name string, def, min int) (int, error) {
+	s := query.Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("%s must be an integer of at least %d", name, min)
+	}
+	return n, nil
+}
+
+func wantsJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeHTML(w http.ResponseWriter, t *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = t.Execute(w, data)
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// formatDuration formats a duration rounded to milliseconds, zero being formatted as empty.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	if d > time.Millisecond {
+		d = d.Round(time.Millisecond)
+	}
+	return d.String()
+}
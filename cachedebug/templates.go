@@ -0,0 +1,95 @@
+package cachedebug
+
+import (
+	"github.com/go-playground/cache/internal/stats"
+	"html/template"
+)
+
+const style = `<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.75em; text-align: left; }
+form { display: inline; }
+</style>`
+
+var funcs = template.FuncMap{
+	"stat": func(fields []stats.Field, name string) string {
+		for _, f := range fields {
+			if f.Name == name {
+				return f.Value
+			}
+		}
+		return ""
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"sub": func(a, b int) int {
+		return max(0, a-b)
+	},
+}
+
+var indexTemplate = template.Must(template.New("index").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Caches</title>` + style + `</head>
+<body>
+<h1>Caches</h1>
+<table>
+<tr><th>Name</th><th>Policy</th><th>Capacity</th><th>Len</th><th>Hits</th><th>Misses</th><th>Max Age</th></tr>
+{{- range .}}
+<tr>
+<td><a href="?name={{.Name}}">{{.Name}}</a></td>
+<td>{{.Policy}}</td>
+<td>{{stat .Fields "Capacity"}}</td>
+<td>{{stat .Fields "Len"}}</td>
+<td>{{stat .Fields "Hits"}}</td>
+<td>{{stat .Fields "Misses"}}</td>
+<td>{{or .MaxAge "none"}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+var detailTemplate = template.Must(template.New("detail").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Name}}</title>` + style + `</head>
+<body>
+<p><a href="?">Caches</a></p>
+<h1>{{.Name}} ({{.Policy}})</h1>
+<table>
+<tr><th>Max Age</th><td>{{or .MaxAge "none"}}</td></tr>
+{{- range .Fields}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+<form method="post" action="?name={{.Name}}"><input type="hidden" name="action" value="clear"><button>Clear</button></form>
+<h2>Keys</h2>
+<table>
+<tr><th>#</th><th>Key</th><th>Age</th><th>TTL</th>{{if eq .Policy "lfu"}}<th>Frequency</th>{{end}}<th></th></tr>
+{{- $detail := .}}
+{{- range $i, $key := .Keys}}
+<tr>
+<td>{{add $detail.Offset $i}}</td>
+<td>{{$key.Key}}</td>
+<td>{{$key.Age}}</td>
+<td>{{$key.TTL}}</td>
+{{- if eq $detail.Policy "lfu"}}
+<td>{{$key.Frequency}}</td>
+{{- end}}
+<td><form method="post" action="?name={{$detail.Name}}"><input type="hidden" name="action" value="evict"><input type="hidden" name="key" value="{{$key.Key}}"><button>Evict</button></form></td>
+</tr>
+{{- end}}
+</table>
+<p>
+{{- if gt .Offset 0}}
+<a href="?name={{.Name}}&amp;offset={{sub .Offset .Limit}}&amp;limit={{.Limit}}">Previous</a>
+{{- end}}
+{{- if .More}}
+<a href="?name={{.Name}}&amp;offset={{add .Offset .Limit}}&amp;limit={{.Limit}}">Next</a>
+{{- end}}
+</p>
+</body>
+</html>
+`))
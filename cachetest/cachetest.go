@@ -0,0 +1,317 @@
+package cachetest
+
+import (
+	timeext "github.com/go-playground/pkg/v5/time"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Cache is the set of functions exercised, satisfied by the lru and lfu ThreadSafeCache along with lru.ConcurrentCache
+// and lfu.ShardedCache using int keys and values, S being their Stats type. It must be safe for concurrent use.
+type Cache[S any] interface {
+	Set(key, value int)
+	Get(key int) optionext.Option[int]
+	Remove(key int)
+	Clear()
+	Stats() S
+}
+
+// Config is the configuration a Factory must build a cache with.
+type Config struct {
+	// Capacity is the maximum number of entries.
+	Capacity int
+
+	// MaxAge is the maximum age of an entry before it must no longer be returned, zero being no max age.
+	MaxAge time.Duration
+
+	// Clock returns the current instant entries must be aged by, such as passed to the lru and lfu Clock builder
+	// functions.
+	Clock func() timeext.Instant
+}
+
+// Factory builds a new empty cache according to the Config, called once per test.
+type Factory[S any] func(cfg Config) Cache[S]
+
+// Policy is the eviction policy the model-based test requires a cache to follow exactly.
+type Policy uint8
+
+const (
+	// Any requires the cache to remain within capacity and never return a value other than the last set for a key,
+	// nor one removed, cleared or expired, without requiring which entries are evicted. Suitable for caches with
+	// approximate eviction such as lru.ConcurrentCache and lfu.ShardedCache.
+	Any Policy = iota
+
+	// LRU requires evicting the least recently used entry, exactly as lru.Cache does.
+	LRU
+
+	// LFU requires evicting the least frequently used entry, least recently used within the same frequency, exactly
+	// as lfu.Cache does.
+	LFU
+)
+
+// Options configures RunConformance.
+type Options struct {
+	// Policy is the eviction policy required by the model-based test.
+	//
+	// Default is Any.
+	Policy Policy
+
+	// Ops is the number of random operations performed by the model-based test.
+	//
+	// Default is 10,000.
+	Ops int
+
+	// Seed seeds the model-based test, which logs the seed used on failure so it can be reproduced.
+	//
+	// Default is random.
+	Seed uint64
+}
+
+// Clock is a fake monotonic clock which only moves when advanced.
+type Clock struct {
+	now atomic.Int64
+}
+
+// Now returns the current instant.
+func (c *Clock) Now() timeext.Instant {
+	return timeext.Instant(c.now.Load())
+}
+
+// Advance moves the clock forward by the duration.
+func (c *Clock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+// RunConformance runs the conformance suite against caches built by the factory, each as its own subtest, covering
+// Set, Get, Remove, Clear and Stats semantics, capacity bounds, MaxAge expiry using a fake Clock, concurrent use and a
+// randomized model-based test against a reference implementation. Run with -race to detect data races.
+//
+// Stats fields named Capacity, Len, Hits, Misses, Gets, Sets, Removals and Evictions, as the lru and lfu Stats have,
+// are checked when present. Stats are expected to return the delta since the previous call or Clear, which resets them.
+func RunConformance[S any](t *testing.T, factory Factory[S], opts Options) {
+	t.Helper()
+	if opts.Ops == 0 {
+		opts.Ops = 10_000
+	}
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+	t.Run("SetGet", func(t *testing.T) { testSetGet(t, factory) })
+	t.Run("Remove", func(t *testing.T) { testRemove(t, factory) })
+	t.Run("Clear", func(t *testing.T) { testClear(t, factory) })
+	t.Run("Stats", func(t *testing.T) { testStats(t, factory) })
+	t.Run("Capacity", func(t *testing.T) { testCapacity(t, factory) })
+	t.Run("MaxAge", func(t *testing.T) { testMaxAge(t, factory) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, factory) })
+	t.Run("Model", func(t *testing.T) { testModel(t, factory, opts) })
+}
+
+func build[S any](factory Factory[S], capacity int, maxAge time.Duration) (Cache[S], *Clock) {
+	clock := new(Clock)
+	return factory(Config{Capacity: capacity, MaxAge: maxAge, Clock: clock.Now}), clock
+}
+
+func expectGet[S any](t *testing.T, c Cache[S], key int, expected optionext.Option[int]) {
+	t.Helper()
+	if actual := c.Get(key); actual != expected {
+		t.Fatalf("Get(%d) returned %v, expected %v", key, actual, expected)
+	}
+}
+
+func testSetGet[S any](t *testing.T, factory Factory[S]) {
+	c, _ := build(factory, 16, 0)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	expectGet(t, c, 1, optionext.Some(1))
+	expectGet(t, c, 2, optionext.Some(2))
+	expectGet(t, c, 3, optionext.None[int]())
+
+	c.Set(1, 10)
+	expectGet(t, c, 1, optionext.Some(10))
+}
+
+func testRemove[S any](t *testing.T, factory Factory[S]) {
+	c, _ := build(factory, 16, 0)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Remove(1)
+	c.Remove(3) // noop
+	expectGet(t, c, 1, optionext.None[int]())
+	expectGet(t, c, 2, optionext.Some(2))
+
+	c.Set(1, 10)
+	expectGet(t, c, 1, optionext.Some(10))
+}
+
+func testClear[S any](t *testing.T, factory Factory[S]) {
+	c, _ := build(factory, 16, 0)
+	for i := 0; i < 8; i++ {
+		c.Set(i, i)
+	}
+	c.Clear()
+	for i := 0; i < 8; i++ {
+		expectGet(t, c, i, optionext.None[int]())
+	}
+	expectStats(t, c.Stats(), map[string]int{"Len": 0, "Sets": 0, "Removals": 0})
+
+	c.Set(1, 1)
+	expectGet(t, c, 1, optionext.Some(1))
+}
+
+func testStats[S any](t *testing.T, factory Factory[S]) {
+	c, _ := build(factory, 16, 0)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Set(1, 10)
+	c.Get(1)
+	c.Get(3)
+	c.Remove(2)
+	c.Remove(4)
+	expectStats(t, c.Stats(), map[string]int{
+		"Capacity":  16,
+		"Len":       1,
+		"Hits":      1,
+		"Misses":    1,
+		"Gets":      2,
+		"Sets":      3,
+		"Removals":  1,
+		"Evictions": 0,
+	})
+
+	// deltas since the previous call, apart from gauges.
+	expectStats(t, c.Stats(), map[string]int{
+		"Capacity": 16,
+		"Len":      1,
+		"Hits":     0,
+		"Misses":   0,
+		"Gets":     0,
+		"Sets":     0,
+		"Removals": 0,
+	})
+}
+
+func testCapacity[S any](t *testing.T, factory Factory[S]) {
+	const capacity = 16
+	c, _ := build(factory, capacity, 0)
+	for i := 0; i < capacity*4; i++ {
+		c.Set(i, i)
+	}
+	if n, found := stat(c.Stats(), "Len"); found && n > capacity {
+		t.Fatalf("Len of %d exceeds capacity of %d", n, capacity)
+	}
+	expectGet(t, c, capacity*4-1, optionext.Some(capacity*4-1))
+
+	var present int
+	for i := 0; i < capacity*4; i++ {
+		if result := c.Get(i); result.IsSome() {
+			present++
+			if result.Unwrap() != i {
+				t.Fatalf("Get(%d) returned %d", i, result.Unwrap())
+			}
+		}
+	}
+	if present > capacity {
+		t.Fatalf("%d entries present exceeds capacity of %d", present, capacity)
+	}
+}
+
+func testMaxAge[S any](t *testing.T, factory Factory[S]) {
+	c, clock := build(factory, 16, time.Minute)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	clock.Advance(30 * time.Second)
+	expectGet(t, c, 1, optionext.Some(1))
+	c.Set(2, 20) // refreshes the entry
+
+	clock.Advance(31 * time.Second)
+	expectGet(t, c, 1, optionext.None[int]())
+	expectGet(t, c, 2, optionext.Some(20))
+	expectStats(t, c.Stats(), map[string]int{"Len": 1, "Evictions": 1})
+
+	c.Set(1, 10)
+	expectGet(t, c, 1, optionext.Some(10))
+}
+
+func testConcurrency[S any](t *testing.T, factory Factory[S]) {
+	const (
+		goroutines = 8
+		ops        = 2_000
+		capacity   = 32
+	)
+	c, clock := build(factory, capacity, time.Minute)
+
+	var wg sync.WaitGroup
+	var gets atomic.Int64
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(seed, seed))
+			for i := 0; i < ops; i++ {
+				key := r.IntN(capacity * 2)
+				switch n := r.IntN(100); {
+				case n < 50:
+					gets.Add(1)
+					if result := c.Get(key); result.IsSome() && result.Unwrap() != key {
+						t.Errorf("Get(%d) returned %d", key, result.Unwrap())
+						return
+					}
+				case n < 90:
+					c.Set(key, key)
+				case n < 98:
+					c.Remove(key)
+				case n < 99:
+					clock.Advance(time.Second)
+				default:
+					c.Clear()
+				}
+			}
+		}(uint64(g))
+	}
+	wg.Wait()
+
+	s := c.Stats()
+	if n, found := stat(s, "Len"); found && n > capacity {
+		t.Fatalf("Len of %d exceeds capacity of %d", n, capacity)
+	}
+	// Clear resets Stats so only the gets since the last are counted.
+	if n, found := stat(s, "Gets"); found && n > int(gets.Load()) {
+		t.Fatalf("Stats Gets of %d exceeds the %d gets performed", n, gets.Load())
+	}
+}
+
+// stat returns the value of the named integer Stats field, if present.
+func stat(s any, name string) (int, bool) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+	f := v.FieldByName(name)
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(f.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(f.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// expectStats fails the test if any of the named Stats fields present differ from those expected.
+func expectStats(t *testing.T, s any, expected map[string]int) {
+	t.Helper()
+	for _, name := range []string{"Capacity", "Len", "Hits", "Misses", "Gets", "Sets", "Removals", "Evictions"} {
+		want, check := expected[name]
+		if !check {
+			continue
+		}
+		if got, found := stat(s, name); found && got != want {
+			t.Fatalf("Stats %s is %d, expected %d", name, got, want)
+		}
+	}
+}
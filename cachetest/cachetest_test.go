@@ -0,0 +1,59 @@
+package cachetest
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	var clock Clock
+	start := clock.Now()
+	clock.Advance(time.Second)
+	Equal(t, clock.Now().Since(start), time.Second)
+	Equal(t, start.Since(clock.Now()), time.Duration(0))
+}
+
+func TestStat(t *testing.T) {
+	s := struct {
+		Len  int
+		Hits uint
+		Name string
+	}{Len: 2, Hits: 3}
+
+	n, found := stat(s, "Len")
+	Equal(t, found, true)
+	Equal(t, n, 2)
+	n, found = stat(s, "Hits")
+	Equal(t, found, true)
+	Equal(t, n, 3)
+	_, found = stat(s, "Name")
+	Equal(t, found, false)
+	_, found = stat(s, "Misses")
+	Equal(t, found, false)
+	_, found = stat(1, "Len")
+	Equal(t, found, false)
+}
+
+func TestModel(t *testing.T) {
+	var clock Clock
+	m := newModel(LFU, Config{Capacity: 2, MaxAge: time.Second, Clock: clock.Now})
+	m.set(1, 1)
+	m.set(2, 2)
+	m.get(1)
+	m.set(3, 3) // evicts 2 as least frequently used
+	Equal(t, m.get(2).IsNone(), true)
+	Equal(t, m.get(1).Unwrap(), 1)
+
+	clock.Advance(2 * time.Second)
+	Equal(t, m.get(3).IsNone(), true)
+	Equal(t, m.takeStats(), map[string]int{
+		"Capacity":  2,
+		"Len":       1,
+		"Sets":      3,
+		"Gets":      4,
+		"Hits":      2,
+		"Misses":    1,
+		"Evictions": 2,
+	})
+}
@@ -0,0 +1,194 @@
+package cachetest
+
+import (
+	timeext "github.com/go-playground/pkg/v5/time"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+type modelEntry struct {
+	value     int
+	timestamp timeext.Instant
+	frequency int
+	// touched orders entries by recency, the higher the more recent.
+	touched uint64
+}
+
+// model is the reference implementation the model-based test compares a cache against. Eviction candidates are found
+// by scanning all entries, which is simple and fast enough for the small capacities tested.
+//
+// As with the lru and lfu caches, an expired entry found by Get counts as an eviction rather than a miss and expired
+// entries continue to occupy capacity until found or evicted.
+type model struct {
+	policy   Policy
+	capacity int
+	maxAge   time.Duration
+	now      func() timeext.Instant
+	entries  map[int]*modelEntry
+	touches  uint64
+	stats    map[string]int
+}
+
+func newModel(policy Policy, cfg Config) *model {
+	return &model{
+		policy:   policy,
+		capacity: cfg.Capacity,
+		maxAge:   cfg.MaxAge,
+		now:      cfg.Clock,
+		entries:  make(map[int]*modelEntry),
+		stats:    make(map[string]int),
+	}
+}
+
+func (m *model) touch(e *modelEntry) {
+	m.touches++
+	e.touched = m.touches
+}
+
+func (m *model) expired(e *modelEntry) bool {
+	return m.maxAge > 0 && m.now().Since(e.timestamp) > m.maxAge
+}
+
+func (m *model) set(key, value int) {
+	m.stats["Sets"]++
+	e, found := m.entries[key]
+	if !found {
+		if m.policy != Any && len(m.entries) >= m.capacity {
+			m.evict()
+		}
+		e = &modelEntry{frequency: 1}
+		m.entries[key] = e
+	}
+	e.value = value
+	e.timestamp = m.now()
+	m.touch(e)
+}
+
+// evict removes the least recently used, or least frequently used, entry.
+func (m *model) evict() {
+	var victim int
+	var least *modelEntry
+	for key, e := range m.entries {
+		if least == nil || m.before(e, least) {
+			victim, least = key, e
+		}
+	}
+	delete(m.entries, victim)
+	m.stats["Evictions"]++
+}
+
+// before returns if a should be evicted before b.
+func (m *model) before(a, b *modelEntry) bool {
+	if m.policy == LFU && a.frequency != b.frequency {
+		return a.frequency < b.frequency
+	}
+	return a.touched < b.touched
+}
+
+// get returns the expected result, which for the Any policy is the only value the cache may return if any.
+func (m *model) get(key int) optionext.Option[int] {
+	m.stats["Gets"]++
+	e, found := m.entries[key]
+	if !found {
+		m.stats["Misses"]++
+		return optionext.None[int]()
+	}
+	if m.expired(e) {
+		delete(m.entries, key)
+		m.stats["Evictions"]++
+		return optionext.None[int]()
+	}
+	e.frequency++
+	m.touch(e)
+	m.stats["Hits"]++
+	return optionext.Some(e.value)
+}
+
+func (m *model) remove(key int) {
+	if _, found := m.entries[key]; found {
+		delete(m.entries, key)
+		m.stats["Removals"]++
+	}
+}
+
+func (m *model) clear() {
+	clear(m.entries)
+	m.stats = make(map[string]int)
+}
+
+// takeStats returns the Stats delta since the previous call.
+func (m *model) takeStats() map[string]int {
+	stats := m.stats
+	stats["Capacity"] = m.capacity
+	stats["Len"] = len(m.entries)
+	m.stats = make(map[string]int)
+	return stats
+}
+
+func testModel[S any](t *testing.T, factory Factory[S], opts Options) {
+	const capacity = 16
+	clock := new(Clock)
+	cfg := Config{Capacity: capacity, MaxAge: time.Second, Clock: clock.Now}
+	c := factory(cfg)
+	m := newModel(opts.Policy, cfg)
+	r := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+
+	fail := func(i int, format string, args ...any) {
+		t.Helper()
+		t.Fatalf("seed %d op %d: "+format, append([]any{opts.Seed, i}, args...)...)
+	}
+	var gets, sets, hits int
+
+	for i := 0; i < opts.Ops; i++ {
+		key := r.IntN(capacity * 2)
+		switch n := r.IntN(100); {
+		case n < 45:
+			gets++
+			expected := m.get(key)
+			actual := c.Get(key)
+			if actual.IsSome() {
+				hits++
+			}
+			switch {
+			case m.policy != Any && actual != expected:
+				fail(i, "Get(%d) returned %v, expected %v", key, actual, expected)
+			case actual.IsSome() && actual != expected:
+				fail(i, "Get(%d) returned %v, which is not the current value %v", key, actual, expected)
+			}
+		case n < 85:
+			sets++
+			value := r.Int()
+			m.set(key, value)
+			c.Set(key, value)
+		case n < 93:
+			m.remove(key)
+			c.Remove(key)
+		case n < 94:
+			m.clear()
+			c.Clear()
+			gets, sets, hits = 0, 0, 0
+		default:
+			clock.Advance(time.Duration(r.IntN(400)) * time.Millisecond)
+		}
+
+		if i%500 == 499 || i == opts.Ops-1 {
+			s := c.Stats()
+			if n, found := stat(s, "Len"); found && n > capacity {
+				fail(i, "Len of %d exceeds capacity of %d", n, capacity)
+			}
+			expected := m.takeStats()
+			if m.policy == Any {
+				// only the counts of the operations themselves are known.
+				expected = map[string]int{"Gets": gets, "Sets": sets, "Hits": hits}
+			}
+			for name, want := range expected {
+				if got, found := stat(s, name); found && got != want {
+					fail(i, "Stats %s is %d, expected %d", name, got, want)
+				}
+			}
+			gets, sets, hits = 0, 0, 0
+		}
+	}
+}
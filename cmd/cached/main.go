@@ -0,0 +1,76 @@
+// Command cached serves an lru or lfu cache over the memcached text protocol on a TCP or Unix socket so processes
+// written in other languages can share it using any memcached client.
+//
+// Usage:
+//
+//	cached -network tcp -addr 127.0.0.1:11211 -policy lru -capacity 67108864 -max-age 1h
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"github.com/go-playground/cache/memcached"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	network := flag.String("network", "tcp", "network to listen on, tcp or unix")
+	addr := flag.String("addr", "127.0.0.1:11211", "address or socket path to listen on")
+	policy := flag.String("policy", "lru", "eviction policy, lru or lfu")
+	capacity := flag.Int("capacity", 64<<20, "maximum bytes of keys and values to store")
+	maxAge := flag.Duration("max-age", 0, "maximum age of items stored without an exptime, 0 for no max age")
+	maxItemSize := flag.Int("max-item-size", 1<<20, "largest value in bytes that may be stored")
+	flag.Parse()
+
+	if *network == "unix" {
+		// remove a stale socket left by a previous run, never any other kind of file.
+		if fi, err := os.Lstat(*addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(*addr)
+		}
+	}
+	l, err := net.Listen(*network, *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var serve func(net.Listener) error
+	var closeFn func() error
+	switch *policy {
+	case "lru":
+		server := memcached.New(lru.New[string, *memcached.Item](*capacity).
+			MaxAge(*maxAge).
+			Weigher(memcached.Weigh).
+			BuildThreadSafe()).MaxItemSize(*maxItemSize).Build()
+		serve, closeFn = server.Serve, server.Close
+	case "lfu":
+		server := memcached.New(lfu.New[string, *memcached.Item](*capacity).
+			MaxAge(*maxAge).
+			Weigher(memcached.Weigh).
+			BuildThreadSafe()).MaxItemSize(*maxItemSize).Build()
+		serve, closeFn = server.Serve, server.Close
+	default:
+		log.Fatalf("unknown policy %q, must be lru or lfu", *policy)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = closeFn()
+	}()
+
+	log.Printf("serving %s cache of %d bytes on %s %s", *policy, *capacity, *network, l.Addr())
+	start := time.Now()
+	if err = serve(l); err != nil && !errors.Is(err, memcached.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	log.Printf("stopped after %s", time.Since(start).Round(time.Second))
+}
@@ -0,0 +1,132 @@
+// Command cachesim replays access traces through the caches at multiple capacities to compare policies and choose a
+// capacity offline, reporting the hit ratio table and miss ratio curve.
+//
+// Every access is a Get, followed by a Set on a miss. Traces may be plain, one key per line, CSV, the ARC and LIRS
+// academic trace formats or traces recorded by the trace package, optionally gzip compressed.
+//
+// Usage:
+//
+//	cachesim -format arc -policies lru,lfu -capacities 1000,10000,100000 -output csv trace.arc.gz
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	format := flag.String("format", "plain", "trace format, plain, csv, arc, lirs or trace")
+	column := flag.Int("column", 0, "zero based column holding the key for the csv format")
+	header := flag.Bool("header", false, "skip the first record for the csv format")
+	policyList := flag.String("policies", "lru,lfu", "comma separated policies to simulate, "+strings.Join(policyNames(), ", "))
+	capacityList := flag.String("capacities", "", "comma separated capacities, default 10 spaced from 1% to 100% of unique keys")
+	output := flag.String("output", "table", "output format, table, csv or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [trace files, - or none for stdin]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	parse, err := parserFor(*format, *column, *header)
+	if err != nil {
+		log.Fatal(err)
+	}
+	t, err := readTrace(flag.Args(), parse)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	capacities := defaultCapacities(t.unique(), 10)
+	if *capacityList != "" {
+		if capacities, err = parseCapacities(*capacityList); err != nil {
+			log.Fatal(err)
+		}
+	}
+	results, err := run(strings.Split(*policyList, ","), capacities, t.accesses)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = write(os.Stdout, *output, results, len(t.accesses), t.unique()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseCapacities(s string) ([]int, error) {
+	var capacities []int
+	for _, field := range strings.Split(s, ",") {
+		c, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || c <= 0 {
+			return nil, fmt.Errorf("invalid capacity %q, must be a positive integer", field)
+		}
+		capacities = append(capacities, c)
+	}
+	return capacities, nil
+}
+
+// write outputs the results as a hit ratio table with a column per policy, or as the miss ratio curve in CSV or JSON.
+func write(w io.Writer, output string, results []result, accesses, unique int) error {
+	switch output {
+	case "table":
+		var policies []string
+		var capacities []int
+		ratios := make(map[string]map[int]float64)
+		for _, r := range results {
+			if ratios[r.Policy] == nil {
+				policies = append(policies, r.Policy)
+				ratios[r.Policy] = make(map[int]float64)
+			}
+			if len(policies) == 1 {
+				capacities = append(capacities, r.Capacity)
+			}
+			ratios[r.Policy][r.Capacity] = r.HitRatio
+		}
+		fmt.Fprintf(w, "accesses: %d, unique keys: %d\n\n", accesses, unique)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(tw, "capacity\t")
+		for _, p := range policies {
+			fmt.Fprintf(tw, "%s\t", p)
+		}
+		fmt.Fprintln(tw)
+		for _, c := range capacities {
+			fmt.Fprintf(tw, "%d\t", c)
+			for _, p := range policies {
+				fmt.Fprintf(tw, "%.2f%%\t", ratios[p][c]*100)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"policy", "capacity", "hits", "misses", "hit_ratio", "miss_ratio"})
+		for _, r := range results {
+			_ = cw.Write([]string{
+				r.Policy,
+				strconv.Itoa(r.Capacity),
+				strconv.FormatUint(r.Hits, 10),
+				strconv.FormatUint(r.Misses, 10),
+				strconv.FormatFloat(r.HitRatio, 'f', 6, 64),
+				strconv.FormatFloat(r.MissRatio, 'f', 6, 64),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Accesses int      `json:"accesses"`
+			Unique   int      `json:"unique"`
+			Results  []result `json:"results"`
+		}{accesses, unique, results})
+	default:
+		return fmt.Errorf("unknown output %q, must be table, csv or json", output)
+	}
+}
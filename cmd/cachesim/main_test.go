@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	. "github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, format, input string, column int, header bool) *trace {
+	p, err := parserFor(format, column, header)
+	Equal(t, err, nil)
+	tr := newTrace()
+	Equal(t, p(strings.NewReader(input), tr), nil)
+	return tr
+}
+
+func TestParsers(t *testing.T) {
+	tr := parse(t, "plain", "a\n# comment\n\nb\na\n", 0, false)
+	Equal(t, tr.accesses, []uint32{0, 1, 0})
+	Equal(t, tr.unique(), 2)
+
+	tr = parse(t, "csv", "ts,key\n1,a\n2,b\n3,a\n", 1, true)
+	Equal(t, tr.accesses, []uint32{0, 1, 0})
+
+	tr = parse(t, "arc", "10 3 0 1\n11 1 0 2\n", 0, false)
+	Equal(t, tr.accesses, []uint32{0, 1, 2, 1})
+	Equal(t, tr.unique(), 3)
+
+	tr = parse(t, "lirs", "5\n6\n*\n5\n", 0, false)
+	Equal(t, tr.accesses, []uint32{0, 1, 0})
+
+	tr = parse(t, "trace", "1 S a\n2 M b\n3 H a\n4 R a\n", 0, false)
+	Equal(t, tr.accesses, []uint32{0, 1})
+
+	p, _ := parserFor("lirs", 0, false)
+	NotEqual(t, p(strings.NewReader("x\n"), newTrace()), nil)
+	p, _ = parserFor("csv", 3, false)
+	NotEqual(t, p(strings.NewReader("a,b\n"), newTrace()), nil)
+	_, err := parserFor("bogus", 0, false)
+	NotEqual(t, err, nil)
+}
+
+func TestSimulate(t *testing.T) {
+	// a b c a b c with capacity 2 thrashes LRU while capacity 3 only misses compulsorily.
+	accesses := []uint32{0, 1, 2, 0, 1, 2}
+	r := simulate("lru", 2, accesses)
+	Equal(t, r.Hits, uint64(0))
+	Equal(t, r.Misses, uint64(6))
+	r = simulate("lru", 3, accesses)
+	Equal(t, r.Hits, uint64(3))
+	Equal(t, r.HitRatio, 0.5)
+	Equal(t, r.MissRatio, 0.5)
+
+	results, err := run([]string{"lru", "lfu"}, []int{1, 3}, accesses)
+	Equal(t, err, nil)
+	Equal(t, len(results), 4)
+	Equal(t, results[1].Policy, "lru")
+	Equal(t, results[1].Capacity, 3)
+	Equal(t, results[3].Policy, "lfu")
+	Equal(t, results[3].Hits, uint64(3))
+
+	// fewer shards than the default at small capacities.
+	r = simulate("lfu-sharded", 2, accesses)
+	Equal(t, r.Hits+r.Misses, uint64(6))
+
+	_, err = run([]string{"bogus"}, []int{1}, accesses)
+	NotEqual(t, err, nil)
+}
+
+func TestDefaultCapacities(t *testing.T) {
+	Equal(t, defaultCapacities(0, 10), []int(nil))
+	Equal(t, defaultCapacities(5, 10), []int{1, 2, 3, 4, 5})
+	c := defaultCapacities(10_000, 10)
+	Equal(t, len(c), 10)
+	Equal(t, c[0], 100)
+	Equal(t, c[9], 10_000)
+}
+
+func TestWrite(t *testing.T) {
+	results := []result{
+		{Policy: "lru", Capacity: 1, Hits: 1, Misses: 3, HitRatio: 0.25, MissRatio: 0.75},
+		{Policy: "lfu", Capacity: 1, Hits: 2, Misses: 2, HitRatio: 0.5, MissRatio: 0.5},
+	}
+	var buf bytes.Buffer
+	Equal(t, write(&buf, "csv", results, 4, 2), nil)
+	Equal(t, buf.String(), "policy,capacity,hits,misses,hit_ratio,miss_ratio\nlru,1,1,3,0.250000,0.750000\nlfu,1,2,2,0.500000,0.500000\n")
+
+	buf.Reset()
+	Equal(t, write(&buf, "table", results, 4, 2), nil)
+	Equal(t, strings.Contains(buf.String(), "25.00%"), true)
+	Equal(t, strings.Contains(buf.String(), "50.00%"), true)
+
+	buf.Reset()
+	Equal(t, write(&buf, "json", results, 4, 2), nil)
+	Equal(t, strings.Contains(buf.String(), `"miss_ratio": 0.75`), true)
+
+	NotEqual(t, write(&buf, "bogus", results, 4, 2), nil)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// simCache is the subset of cache functions used to replay a trace.
+type simCache interface {
+	Get(key uint32) optionext.Option[struct{}]
+	Set(key uint32, value struct{})
+}
+
+// policies are the caches which can be simulated by name.
+var policies = map[string]func(capacity int) simCache{
+	"lru": func(capacity int) simCache {
+		return lru.New[uint32, struct{}](capacity).Build()
+	},
+	"lru-concurrent": func(capacity int) simCache {
+		return lru.New[uint32, struct{}](capacity).BuildConcurrent()
+	},
+	"lfu": func(capacity int) simCache {
+		return lfu.New[uint32, struct{}](capacity).Build()
+	},
+	"lfu-sharded": func(capacity int) simCache {
+		return lfu.New[uint32, struct{}](capacity).BuildSharded(min(16, capacity))
+	},
+}
+
+func policyNames() []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// result is the outcome of replaying a trace through a policy at a capacity.
+type result struct {
+	Policy    string  `json:"policy"`
+	Capacity  int     `json:"capacity"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+	MissRatio float64 `json:"miss_ratio"`
+}
+
+// simulate replays the accesses, setting every miss, and counts the hits and misses.
+func simulate(policy string, capacity int, accesses []uint32) result {
+	cache := policies[policy](capacity)
+	r := result{Policy: policy, Capacity: capacity}
+	for _, key := range accesses {
+		if cache.Get(key).IsSome() {
+			r.Hits++
+		} else {
+			r.Misses++
+			cache.Set(key, struct{}{})
+		}
+	}
+	if total := r.Hits + r.Misses; total > 0 {
+		r.HitRatio = float64(r.Hits) / float64(total)
+		r.MissRatio = float64(r.Misses) / float64(total)
+	}
+	return r
+}
+
+// run simulates every policy at every capacity in parallel, returning results ordered by policy then capacity.
+func run(names []string, capacities []int, accesses []uint32) ([]result, error) {
+	for _, name := range names {
+		if _, found := policies[name]; !found {
+			return nil, fmt.Errorf("unknown policy %q, must be one of %v", name, policyNames())
+		}
+	}
+
+	results := make([]result, len(names)*len(capacities))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = simulate(names[i/len(capacities)], capacities[i%len(capacities)], accesses)
+			}
+		}()
+	}
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// defaultCapacities returns count capacities spaced logarithmically from 1% to 100% of the number of unique keys.
+func defaultCapacities(unique, count int) []int {
+	if unique == 0 {
+		return nil
+	}
+	var capacities []int
+	low, high := math.Log(math.Max(1, float64(unique)/100)), math.Log(float64(unique))
+	for i := 0; i < count; i++ {
+		c := int(math.Round(math.Exp(low + (high-low)*float64(i)/float64(max(count-1, 1)))))
+		if len(capacities) == 0 || c > capacities[len(capacities)-1] {
+			capacities = append(capacities, c)
+		}
+	}
+	return capacities
+}
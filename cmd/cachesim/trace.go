@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	cachetrace "github.com/go-playground/cache/trace"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// trace is a sequence of accesses with each distinct key interned to a small integer.
+type trace struct {
+	accesses []uint32
+	ids      map[string]uint32
+}
+
+func newTrace() *trace {
+	return &trace{ids: make(map[string]uint32)}
+}
+
+func (t *trace) add(key string) {
+	id, found := t.ids[key]
+	if !found {
+		id = uint32(len(t.ids))
+		t.ids[key] = id
+	}
+	t.accesses = append(t.accesses, id)
+}
+
+// unique returns the number of distinct keys in the trace.
+func (t *trace) unique() int {
+	return len(t.ids)
+}
+
+// parser reads the accesses of a trace in a particular format.
+type parser func(r io.Reader, t *trace) error
+
+// parserFor returns the parser for the format.
+func parserFor(format string, column int, header bool) (parser, error) {
+	switch format {
+	case "plain":
+		return parsePlain, nil
+	case "csv":
+		return func(r io.Reader, t *trace) error {
+			return parseCSV(r, t, column, header)
+		}, nil
+	case "arc":
+		return parseARC, nil
+	case "lirs":
+		return parseLIRS, nil
+	case "trace":
+		return parseRecorded, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, must be plain, csv, arc, lirs or trace", format)
+	}
+}
+
+// parsePlain reads one key per line, ignoring blank lines and lines starting with #.
+func parsePlain(r io.Reader, t *trace) error {
+	return lines(r, func(line string) error {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			t.add(line)
+		}
+		return nil
+	})
+}
+
+// parseCSV reads the key from the column of each record, optionally skipping a header record.
+func parseCSV(r io.Reader, t *trace, column int, header bool) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first && header {
+			continue
+		}
+		if column >= len(record) {
+			line, _ := cr.FieldPos(0)
+			return fmt.Errorf("line %d has no column %d", line, column)
+		}
+		t.add(record[column])
+	}
+}
+
+// parseARC reads the trace format used by the ARC paper, each line being a starting block, a number of blocks, an
+// ignored field and a request number, expanding into an access for every block in the range.
+func parseARC(r io.Reader, t *trace) error {
+	var n int
+	return lines(r, func(line string) error {
+		n++
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return nil
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("line %d: expected start block and block count", n)
+		}
+		start, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		for i := uint64(0); i < count; i++ {
+			t.add(strconv.FormatUint(start+i, 10))
+		}
+		return nil
+	})
+}
+
+// parseLIRS reads the trace format used by the LIRS paper, one block number per line, ignoring the * lines used as
+// markers in some traces.
+func parseLIRS(r io.Reader, t *trace) error {
+	var n int
+	return lines(r, func(line string) error {
+		n++
+		if line == "" || line == "*" {
+			return nil
+		}
+		if _, err := strconv.ParseUint(line, 10, 64); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		t.add(line)
+		return nil
+	})
+}
+
+// parseRecorded reads a trace written by a trace.Recorder in either format, replaying only its Gets.
+func parseRecorded(r io.Reader, t *trace) error {
+	reader, err := cachetrace.NewReader(r)
+	if err != nil {
+		return err
+	}
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Op.IsGet() {
+			t.add(rec.Key)
+		}
+	}
+}
+
+func lines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		if err := fn(strings.TrimSpace(scanner.Text())); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readTrace reads the files, or stdin if none or -, decompressing files ending in .gz.
+func readTrace(files []string, parse parser) (*trace, error) {
+	t := newTrace()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		if err := readFile(name, parse, t); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+func readFile(name string, parse parser, t *trace) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return parse(r, t)
+}
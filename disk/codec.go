@@ -0,0 +1,45 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec encodes and decodes values to and from bytes for storage on disk.
+type Codec[V any] interface {
+	Marshal(value V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// GobCodec is a Codec using encoding/gob.
+type GobCodec[V any] struct{}
+
+// Marshal encodes the value using gob.
+func (GobCodec[V]) Marshal(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the value using gob.
+func (GobCodec[V]) Unmarshal(data []byte) (value V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return
+}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec[V any] struct{}
+
+// Marshal encodes the value as JSON.
+func (JSONCodec[V]) Marshal(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Unmarshal decodes the value from JSON.
+func (JSONCodec[V]) Unmarshal(data []byte) (value V, err error) {
+	err = json.Unmarshal(data, &value)
+	return
+}
@@ -0,0 +1,367 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	syncext "github.com/go-playground/pkg/v5/sync"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// headerSize is the size of each records header, the length of the encoded value followed by its CRC-32.
+	headerSize = 8
+)
+
+var (
+	// ErrTooLarge is reported when an encoded value can never fit within the max bytes on disk.
+	ErrTooLarge = errors.New("disk: encoded value exceeds max bytes")
+
+	// ErrCorrupt is reported when a record read back from disk fails its checksum.
+	ErrCorrupt = errors.New("disk: corrupt record")
+)
+
+type builder[K comparable, V any] struct {
+	disk *Cache[K, V]
+}
+
+// New initializes a builder to create a disk backed cache storing segment files within dir, bounded by maxBytes.
+func New[K comparable, V any](dir string, maxBytes int64) *builder[K, V] {
+	if maxBytes <= 0 {
+		panic("maxBytes must be a positive value")
+	}
+	return &builder[K, V]{
+		disk: &Cache[K, V]{
+			dir:         dir,
+			codec:       GobCodec[V]{},
+			maxBytes:    maxBytes,
+			segmentSize: max(maxBytes/4, headerSize),
+			onError:     func(error) {},
+		},
+	}
+}
+
+// Codec sets the Codec used to encode values.
+//
+// Default is GobCodec.
+func (b *builder[K, V]) Codec(codec Codec[V]) *builder[K, V] {
+	b.disk.codec = codec
+	return b
+}
+
+// SegmentSize sets the size at which the active segment file is sealed and a new one started. When over max bytes the
+// oldest segment is dropped in its entirety, so smaller segments give finer grained eviction at the cost of more files.
+//
+// Default is a quarter of max bytes.
+func (b *builder[K, V]) SegmentSize(size int64) *builder[K, V] {
+	if size <= 0 {
+		panic("SegmentSize must be a positive value")
+	}
+	if size > b.disk.maxBytes {
+		panic("SegmentSize must not exceed maxBytes")
+	}
+	b.disk.segmentSize = size
+	return b
+}
+
+// OnError sets a function to be called with errors encountered during Set or Get which, to remain interchangeable
+// with the in-memory caches, do not return them. It may be called while the caches lock is held and so must not call
+// back into the same cache.
+//
+// Default ignores errors.
+func (b *builder[K, V]) OnError(fn func(err error)) *builder[K, V] {
+	b.disk.onError = fn
+	return b
+}
+
+// Build finalizes configuration, creating dir if required, and returns the disk cache for use.
+//
+// Segment files are numbered after any already within dir, which are left untouched, so multiple caches may share it.
+func (b *builder[K, V]) Build() (*Cache[K, V], error) {
+	disk := b.disk
+	b.disk = nil
+	if err := os.MkdirAll(disk.dir, 0o700); err != nil {
+		return nil, err
+	}
+	nextID, err := nextSegmentID(disk.dir)
+	if err != nil {
+		return nil, err
+	}
+	disk.store = syncext.NewMutex2(&store[K]{
+		index:  make(map[K]location[K]),
+		nextID: nextID,
+	})
+	return disk, nil
+}
+
+// nextSegmentID returns the ID following the highest of the segment files within dir.
+func nextSegmentID(dir string) (uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var next uint64
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), ".seg")
+		if !found {
+			continue
+		}
+		if id, err := strconv.ParseUint(name, 10, 64); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+	return next, nil
+}
+
+// Stats represents the disk cache statistics.
+type Stats struct {
+	// MaxBytes is the maximum bytes on disk.
+	MaxBytes int64
+
+	// Bytes is the current bytes on disk, including those of removed or replaced entries not yet reclaimed.
+	Bytes int64
+
+	// Len is the current number of entries.
+	Len int
+
+	// Segments is the current number of segment files.
+	Segments int
+
+	// Hits is the number of cache hits.
+	Hits uint
+
+	// Misses is the number of cache misses.
+	Misses uint
+
+	// Sets is the number of cache sets performed.
+	Sets uint
+
+	// Evictions is the number of entries discarded by dropping the oldest segment to remain within max bytes.
+	Evictions uint
+
+	// Errors is the number of errors reported to the OnError function.
+	Errors uint
+}
+
+type location[K comparable] struct {
+	segment *segment[K]
+	offset  int64
+	length  int
+}
+
+type segment[K comparable] struct {
+	file *os.File
+	size int64
+	// keys are those of the records appended to the segment, some of which may since be removed or replaced.
+	keys []K
+}
+
+type store[K comparable] struct {
+	index    map[K]location[K]
+	segments []*segment[K]
+	nextID   uint64
+	bytes    int64
+	stats    Stats
+}
+
+// Cache is an overflow tier storing entries in append-only segment files with an in-memory index, intended to
+// receive entries evicted from an in-memory cache and serve them upon a memory miss.
+//
+// As the index is only held in memory, the segment files are not reusable across restarts and are deleted on Close.
+//
+// Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	dir         string
+	codec       Codec[V]
+	maxBytes    int64
+	segmentSize int64
+	onError     func(err error)
+	store       syncext.Mutex2[*store[K]]
+}
+
+// Set encodes and appends an item to the active segment file. It will replace the current entry if there is one.
+func (c *Cache[K, V]) Set(key K, value V) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	size := int64(headerSize + len(data))
+	if size > c.maxBytes {
+		c.report(ErrTooLarge)
+		return
+	}
+
+	guard := c.store.Lock()
+	defer guard.Unlock()
+	s := guard.T
+	s.stats.Sets++
+
+	active, err := c.active(s, size)
+	if err != nil {
+		c.reportLocked(s, err)
+		return
+	}
+	for s.bytes+size > c.maxBytes && len(s.segments) > 1 {
+		if err = c.drop(s); err != nil {
+			c.reportLocked(s, err)
+		}
+	}
+
+	record := make([]byte, size)
+	binary.LittleEndian.PutUint32(record, uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(data))
+	copy(record[headerSize:], data)
+
+	if _, err = active.file.WriteAt(record, active.size); err != nil {
+		c.reportLocked(s, err)
+		return
+	}
+	s.index[key] = location[K]{
+		segment: active,
+		offset:  active.size + headerSize,
+		length:  len(data),
+	}
+	active.keys = append(active.keys, key)
+	active.size += size
+	s.bytes += size
+}
+
+// active returns the segment to append a record of the provided size to, starting a new segment if required.
+func (c *Cache[K, V]) active(s *store[K], size int64) (*segment[K], error) {
+	if n := len(s.segments); n > 0 && s.segments[n-1].size+size <= c.segmentSize {
+		return s.segments[n-1], nil
+	}
+	for {
+		// exclusive so as never to truncate a segment file of another cache sharing dir.
+		file, err := os.OpenFile(filepath.Join(c.dir, fmt.Sprintf("%020d.seg", s.nextID)), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+		s.nextID++
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		seg := &segment[K]{file: file}
+		s.segments = append(s.segments, seg)
+		return seg, nil
+	}
+}
+
+// drop deletes the oldest segment along with all index entries pointing to it.
+func (c *Cache[K, V]) drop(s *store[K]) error {
+	oldest := s.segments[0]
+	s.segments = s.segments[1:]
+	s.bytes -= oldest.size
+	for _, key := range oldest.keys {
+		if loc, found := s.index[key]; found && loc.segment == oldest {
+			delete(s.index, key)
+			s.stats.Evictions++
+		}
+	}
+	return closeAndRemove(oldest)
+}
+
+// Get attempts to find an existing cache entry by key, reading and decoding it from disk.
+// It returns an Option you must check before using the underlying value.
+func (c *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
+	guard := c.store.Lock()
+	s := guard.T
+	loc, found := s.index[key]
+	if !found {
+		s.stats.Misses++
+		guard.Unlock()
+		return
+	}
+
+	data := make([]byte, headerSize+loc.length)
+	_, err := loc.segment.file.ReadAt(data, loc.offset-headerSize)
+	if err == nil && crc32.ChecksumIEEE(data[headerSize:]) != binary.LittleEndian.Uint32(data[4:]) {
+		err = ErrCorrupt
+	}
+	if err != nil {
+		delete(s.index, key)
+		s.stats.Misses++
+		c.reportLocked(s, err)
+		guard.Unlock()
+		return
+	}
+	s.stats.Hits++
+	guard.Unlock()
+
+	value, err := c.codec.Unmarshal(data[headerSize:])
+	if err != nil {
+		c.report(err)
+		return
+	}
+	return optionext.Some(value)
+}
+
+// Remove removes the item matching the provided key from the cache, if not present is a noop.
+//
+// The bytes on disk are reclaimed when the segment containing them is dropped.
+func (c *Cache[K, V]) Remove(key K) {
+	guard := c.store.Lock()
+	delete(guard.T.index, key)
+	guard.Unlock()
+}
+
+// Clear empties the cache deleting all segment files.
+func (c *Cache[K, V]) Clear() {
+	guard := c.store.Lock()
+	if err := c.clear(guard.T); err != nil {
+		c.reportLocked(guard.T, err)
+	}
+	guard.Unlock()
+}
+
+func (c *Cache[K, V]) clear(s *store[K]) (err error) {
+	for _, seg := range s.segments {
+		err = errors.Join(err, closeAndRemove(seg))
+	}
+	s.segments = nil
+	s.bytes = 0
+	clear(s.index)
+	return
+}
+
+// Close empties the cache deleting all segment files. The cache must not be used after Close.
+func (c *Cache[K, V]) Close() error {
+	guard := c.store.Lock()
+	defer guard.Unlock()
+	return c.clear(guard.T)
+}
+
+// Stats returns the delta of Stats since last call to the Stats function.
+func (c *Cache[K, V]) Stats() (stats Stats) {
+	guard := c.store.Lock()
+	s := guard.T
+	stats = s.stats
+	stats.MaxBytes = c.maxBytes
+	stats.Bytes = s.bytes
+	stats.Len = len(s.index)
+	stats.Segments = len(s.segments)
+	s.stats = Stats{}
+	guard.Unlock()
+	return
+}
+
+func (c *Cache[K, V]) report(err error) {
+	guard := c.store.Lock()
+	c.reportLocked(guard.T, err)
+	guard.Unlock()
+}
+
+func (c *Cache[K, V]) reportLocked(s *store[K], err error) {
+	s.stats.Errors++
+	c.onError(err)
+}
+
+func closeAndRemove[K comparable](seg *segment[K]) error {
+	return errors.Join(seg.file.Close(), os.Remove(seg.file.Name()))
+}
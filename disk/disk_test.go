@@ -0,0 +1,144 @@
+package disk
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lru"
+	"github.com/go-playground/cache/tiered"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiskBadConfig(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, string](t.TempDir(), 0)
+	}, "maxBytes must be a positive value")
+	PanicMatches(t, func() {
+		New[string, string](t.TempDir(), 10).SegmentSize(0)
+	}, "SegmentSize must be a positive value")
+	PanicMatches(t, func() {
+		New[string, string](t.TempDir(), 10).SegmentSize(11)
+	}, "SegmentSize must not exceed maxBytes")
+}
+
+func TestDiskBasics(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New[string, []string](dir, 1<<20).Codec(JSONCodec[[]string]{}).Build()
+	Equal(t, err, nil)
+	defer func() { _ = c.Close() }()
+
+	c.Set("1", []string{"a", "b"})
+	c.Set("2", []string{"c"})
+	c.Set("1", []string{"d"})
+	Equal(t, c.Get("1"), optionext.Some([]string{"d"}))
+	Equal(t, c.Get("2"), optionext.Some([]string{"c"}))
+	Equal(t, c.Get("3"), optionext.None[[]string]())
+
+	c.Remove("2")
+	Equal(t, c.Get("2"), optionext.None[[]string]())
+
+	stats := c.Stats()
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Segments, 1)
+	Equal(t, stats.Hits, uint(2))
+	Equal(t, stats.Misses, uint(2))
+	Equal(t, stats.Sets, uint(3))
+	Equal(t, stats.Bytes > 0, true)
+
+	c.Clear()
+	Equal(t, c.Get("1"), optionext.None[[]string]())
+	files, _ := os.ReadDir(dir)
+	Equal(t, len(files), 0)
+}
+
+func TestDiskMaxBytes(t *testing.T) {
+	var errs []error
+	value := strings.Repeat("x", 100)
+	c, err := New[int, string](t.TempDir(), 1_000).SegmentSize(250).OnError(func(err error) {
+		errs = append(errs, err)
+	}).Build()
+	Equal(t, err, nil)
+	defer func() { _ = c.Close() }()
+
+	for i := 0; i < 20; i++ {
+		c.Set(i, value)
+	}
+	stats := c.Stats()
+	Equal(t, stats.Bytes <= 1_000, true)
+	Equal(t, stats.Len+int(stats.Evictions), 20)
+	Equal(t, stats.Evictions > 0, true)
+	Equal(t, c.Get(0), optionext.None[string]())
+	Equal(t, c.Get(19), optionext.Some(value))
+
+	c.Set(-1, strings.Repeat("x", 1_000))
+	Equal(t, errs, []error{ErrTooLarge})
+	Equal(t, c.Stats().Errors, uint(1))
+}
+
+func TestDiskSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "00000000000000000003.seg")
+	Equal(t, os.WriteFile(leftover, []byte("leftover"), 0o600), nil)
+
+	a, err := New[string, string](dir, 1<<20).Build()
+	Equal(t, err, nil)
+	b, err := New[string, string](dir, 1<<20).Build()
+	Equal(t, err, nil)
+
+	a.Set("1", "a")
+	b.Set("1", "b")
+	Equal(t, a.Get("1"), optionext.Some("a"))
+	Equal(t, b.Get("1"), optionext.Some("b"))
+
+	Equal(t, a.Close(), nil)
+	Equal(t, b.Close(), nil)
+	data, err := os.ReadFile(leftover)
+	Equal(t, err, nil)
+	Equal(t, string(data), "leftover")
+}
+
+func TestDiskCorrupt(t *testing.T) {
+	var errs []error
+	c, err := New[string, string](t.TempDir(), 1<<20).OnError(func(err error) {
+		errs = append(errs, err)
+	}).Build()
+	Equal(t, err, nil)
+	defer func() { _ = c.Close() }()
+
+	c.Set("1", "value")
+	guard := c.store.Lock()
+	loc := guard.T.index["1"]
+	_, err = loc.segment.file.WriteAt([]byte{0xff}, loc.offset)
+	guard.Unlock()
+	Equal(t, err, nil)
+
+	Equal(t, c.Get("1"), optionext.None[string]())
+	Equal(t, errs, []error{ErrCorrupt})
+	Equal(t, c.Stats().Len, 0)
+}
+
+func TestDiskOverflowTier(t *testing.T) {
+	d, err := New[string, int](t.TempDir(), 1<<20).Build()
+	Equal(t, err, nil)
+	defer func() { _ = d.Close() }()
+
+	var memory lru.ThreadSafeCache[string, int]
+	c := tiered.NewDemoting(func(demote func(key string, value int)) tiered.Tier[string, int] {
+		memory = lru.New[string, int](2).OnEvict(func(key string, value int, reason lru.Reason) {
+			if reason == lru.Capacity {
+				demote(key, value)
+			}
+		}).BuildThreadSafe()
+		return memory
+	}, d).Build()
+
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	Equal(t, d.Stats().Len, 8)
+	Equal(t, c.Get("0"), optionext.Some(0))
+	Equal(t, memory.Get("0"), optionext.Some(0))
+}
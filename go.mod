@@ -1,6 +1,6 @@
 module github.com/go-playground/cache
 
-go 1.20
+go 1.24
 
 require github.com/go-playground/pkg/v5 v5.21.2
 
@@ -0,0 +1,189 @@
+package httpcache
+
+import (
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// StatusHeader is the header set on responses reporting how the cache handled the request.
+	StatusHeader = "X-Cache"
+)
+
+// Cache is the set of functions required to store responses, satisfied by the lru and lfu ThreadSafeCache,
+// lru.ConcurrentCache and lfu.ShardedCache using string keys and *Response values.
+//
+// Build the cache with Weigh as its Weigher to bound it by response size rather than count.
+type Cache interface {
+	Get(key string) optionext.Option[*Response]
+	SetWithMaxAge(key string, value *Response, maxAge time.Duration)
+	Remove(key string)
+}
+
+// Response is a cached HTTP response.
+type Response struct {
+	// StatusCode is the responses status code.
+	StatusCode int
+
+	// Header is the responses headers.
+	Header http.Header
+
+	// Body is the responses body.
+	Body []byte
+
+	// Stored is when the response was stored.
+	Stored time.Time
+
+	// vary, when set, marks this as a placeholder listing the request headers which select the stored variant.
+	vary []string
+}
+
+// Weigh returns the approximate size in bytes of a cached response, for use as the caches Weigher.
+func Weigh(key string, r *Response) int {
+	n := len(key) + len(r.Body)
+	for name, values := range r.Header {
+		n += len(name)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	for _, name := range r.vary {
+		n += len(name)
+	}
+	return n
+}
+
+// cacheableStatus are the status codes cacheable by default.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// lifetime returns the responses explicit freshness lifetime from its s-maxage, when a shared cache, max-age or
+// Expires headers. The bool is false if none are present.
+func lifetime(resp *Response, shared bool) (time.Duration, bool) {
+	cc := parseCacheControl(resp.Header)
+	if shared {
+		if maxAge, found := cc.duration("s-maxage"); found {
+			return maxAge, true
+		}
+	}
+	if maxAge, found := cc.duration("max-age"); found {
+		return maxAge, true
+	}
+	if expires := resp.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		return t.Sub(date(resp)), true
+	}
+	return 0, false
+}
+
+// date returns the responses Date header, or when it was stored if absent or invalid.
+func date(resp *Response) time.Time {
+	t, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return resp.Stored
+	}
+	return t
+}
+
+// cacheControl holds parsed Cache-Control directives, lowercased, mapped to their unquoted value if any.
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, line := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, found := cc[directive]
+	return found
+}
+
+// duration returns the directives value as a duration in seconds.
+func (cc cacheControl) duration(directive string) (time.Duration, bool) {
+	value, found := cc[directive]
+	if !found {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// varyNames returns the canonical, sorted request header names the response varies on. The bool is false if the
+// response varies on everything and so cannot be cached.
+func varyNames(h http.Header) ([]string, bool) {
+	var names []string
+	for _, line := range h.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// variantKey returns the key of the variant matching the requests values for the provided header names.
+func variantKey(key string, names []string, h http.Header) string {
+	var sb strings.Builder
+	sb.WriteString(key)
+	for _, name := range names {
+		sb.WriteByte('\n')
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(h.Values(name), ","))
+	}
+	return sb.String()
+}
+
+// etagMatch returns if the If-None-Match header value matches the etag using weak comparison.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModifiedHeaders are the headers sent with a 304 Not Modified response.
+var notModifiedHeaders = []string{"Cache-Control", "Content-Location", "Date", "ETag", "Expires", "Vary"}
@@ -0,0 +1,195 @@
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Options configures the caching Middleware.
+type Options struct {
+	// DefaultMaxAge is how long to cache responses with no explicit freshness information. Zero does not cache them.
+	DefaultMaxAge time.Duration
+
+	// MaxBodySize is the largest response body, in bytes, that will be cached. Zero defaults to 1MiB.
+	MaxBodySize int
+}
+
+// Middleware returns http middleware caching the responses of GET and HEAD requests in the provided cache, as a
+// shared cache, keyed by method, host, URL and any request headers listed in the responses Vary header.
+//
+// Responses are cached for their Cache-Control s-maxage or max-age, otherwise until their Expires, falling back to
+// Options.DefaultMaxAge. Responses marked no-store, no-cache or private, or setting cookies, are not cached, nor are
+// requests carrying Authorization or Cache-Control no-store. Cached responses with an ETag matching the requests
+// If-None-Match are answered with 304 Not Modified. Unsafe methods invalidate the cached response for their URL.
+//
+// The StatusHeader is set to HIT or MISS on every cacheable request.
+func Middleware(cache Cache, opts Options) func(http.Handler) http.Handler {
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := http.MethodGet + " " + r.Host + r.URL.RequestURI()
+
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				if r.Method != http.MethodOptions && r.Method != http.MethodTrace {
+					cache.Remove(key)
+				}
+				return
+			}
+
+			reqCC := parseCacheControl(r.Header)
+			if reqCC.has("no-store") || r.Header.Get("Authorization") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !reqCC.has("no-cache") {
+				if resp, found := lookup(cache, key, r); found {
+					serve(w, r, resp)
+					return
+				}
+			}
+
+			w.Header().Set(StatusHeader, "MISS")
+			rec := &recorder{
+				ResponseWriter: w,
+				max:            opts.MaxBodySize,
+			}
+			next.ServeHTTP(rec, r)
+			if !rec.wroteHeader {
+				rec.WriteHeader(http.StatusOK)
+			}
+
+			if r.Method == http.MethodHead || rec.overflow {
+				return
+			}
+			rec.header.Del(StatusHeader)
+			resp := &Response{
+				StatusCode: rec.status,
+				Header:     rec.header,
+				Body:       rec.body.Bytes(),
+				Stored:     time.Now(),
+			}
+			if maxAge := cacheableFor(resp, opts.DefaultMaxAge); maxAge > 0 {
+				store(cache, key, r, resp, maxAge)
+			}
+		})
+	}
+}
+
+// cacheableFor returns how long the response may be stored in a shared cache, zero if it must not be.
+func cacheableFor(resp *Response, defaultMaxAge time.Duration) time.Duration {
+	if !cacheableStatus[resp.StatusCode] || resp.Header.Get("Set-Cookie") != "" {
+		return 0
+	}
+	cc := parseCacheControl(resp.Header)
+	if cc.has("no-store") || cc.has("no-cache") || cc.has("private") {
+		return 0
+	}
+	if maxAge, found := lifetime(resp, true); found {
+		return maxAge
+	}
+	return defaultMaxAge
+}
+
+// lookup finds the cached response, or the variant of it, matching the request.
+func lookup(cache Cache, key string, r *http.Request) (*Response, bool) {
+	option := cache.Get(key)
+	if option.IsNone() {
+		return nil, false
+	}
+	resp := option.Unwrap()
+	if resp.vary != nil {
+		if option = cache.Get(variantKey(key, resp.vary, r.Header)); option.IsNone() {
+			return nil, false
+		}
+		resp = option.Unwrap()
+	}
+	return resp, true
+}
+
+// store caches the response, along with a placeholder listing the Vary headers if it has any.
+func store(cache Cache, key string, r *http.Request, resp *Response, maxAge time.Duration) {
+	names, ok := varyNames(resp.Header)
+	if !ok {
+		return
+	}
+	if len(names) == 0 {
+		cache.SetWithMaxAge(key, resp, maxAge)
+		return
+	}
+	cache.SetWithMaxAge(key, &Response{vary: names, Stored: resp.Stored}, maxAge)
+	cache.SetWithMaxAge(variantKey(key, names, r.Header), resp, maxAge)
+}
+
+// serve writes the cached response, or 304 Not Modified if the requests If-None-Match matches.
+func serve(w http.ResponseWriter, r *http.Request, resp *Response) {
+	h := w.Header()
+	age := strconv.Itoa(int(time.Since(resp.Stored) / time.Second))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatch(inm, resp.Header.Get("ETag")) {
+		for _, name := range notModifiedHeaders {
+			if values := resp.Header.Values(name); len(values) > 0 {
+				h[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		h.Set("Age", age)
+		h.Set(StatusHeader, "HIT")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	for name, values := range resp.Header {
+		h[name] = values
+	}
+	h.Set("Age", age)
+	h.Set(StatusHeader, "HIT")
+	w.WriteHeader(resp.StatusCode)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(resp.Body)
+	}
+}
+
+// recorder captures the response written by the next handler while passing it through.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	max         int
+	overflow    bool
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader && code >= http.StatusOK {
+		rec.wroteHeader = true
+		rec.status = code
+		rec.header = rec.ResponseWriter.Header().Clone()
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > rec.max {
+			rec.overflow = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for use with http.ResponseController.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,180 @@
+package httpcache
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func request(h http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello " + strconv.Itoa(calls)))
+	})
+	cache := lru.New[string, *Response](1 << 10).Weigher(Weigh).BuildThreadSafe()
+	h := Middleware(cache, Options{})(next)
+
+	w := request(h, http.MethodGet, "/a", nil)
+	Equal(t, w.Code, http.StatusOK)
+	Equal(t, w.Body.String(), "hello 1")
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+
+	w = request(h, http.MethodGet, "/a", nil)
+	Equal(t, w.Body.String(), "hello 1")
+	Equal(t, w.Header().Get(StatusHeader), "HIT")
+	Equal(t, w.Header().Get("Age"), "0")
+	Equal(t, w.Header().Get("ETag"), `"v1"`)
+	Equal(t, calls, 1)
+
+	// HEAD is served from the GET response
+	w = request(h, http.MethodHead, "/a", nil)
+	Equal(t, w.Code, http.StatusOK)
+	Equal(t, w.Body.Len(), 0)
+	Equal(t, w.Header().Get(StatusHeader), "HIT")
+
+	// conditional
+	w = request(h, http.MethodGet, "/a", http.Header{"If-None-Match": {`W/"v0", W/"v1"`}})
+	Equal(t, w.Code, http.StatusNotModified)
+	Equal(t, w.Body.Len(), 0)
+	Equal(t, w.Header().Get("ETag"), `"v1"`)
+
+	// different query is a different key
+	w = request(h, http.MethodGet, "/a?b=c", nil)
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	Equal(t, calls, 2)
+
+	// request no-cache skips the lookup and refreshes the entry
+	w = request(h, http.MethodGet, "/a", http.Header{"Cache-Control": {"no-cache"}})
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	Equal(t, w.Body.String(), "hello 3")
+	w = request(h, http.MethodGet, "/a", nil)
+	Equal(t, w.Body.String(), "hello 3")
+
+	// Authorization bypasses the cache
+	w = request(h, http.MethodGet, "/a", http.Header{"Authorization": {"Bearer x"}})
+	Equal(t, w.Header().Get(StatusHeader), "")
+	Equal(t, w.Body.String(), "hello 4")
+
+	// unsafe methods invalidate
+	_ = request(h, http.MethodPost, "/a", nil)
+	w = request(h, http.MethodGet, "/a", nil)
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	Equal(t, w.Body.String(), "hello 6")
+
+	stats := cache.Stats()
+	Equal(t, stats.Weight > 0, true)
+}
+
+func TestMiddlewareNotCacheable(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{name: "no-store", header: http.Header{"Cache-Control": {"no-store"}}, status: http.StatusOK},
+		{name: "no-cache", header: http.Header{"Cache-Control": {"no-cache"}}, status: http.StatusOK},
+		{name: "private", header: http.Header{"Cache-Control": {"private, max-age=60"}}, status: http.StatusOK},
+		{name: "zero max-age", header: http.Header{"Cache-Control": {"max-age=0"}}, status: http.StatusOK},
+		{name: "cookie", header: http.Header{"Cache-Control": {"max-age=60"}, "Set-Cookie": {"a=b"}}, status: http.StatusOK},
+		{name: "vary all", header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}, status: http.StatusOK},
+		{name: "status", header: http.Header{"Cache-Control": {"max-age=60"}}, status: http.StatusInternalServerError},
+		{name: "no freshness", header: http.Header{}, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				for name, values := range tc.header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(tc.status)
+			})
+			cache := lfu.New[string, *Response](10).BuildThreadSafe()
+			h := Middleware(cache, Options{})(next)
+			_ = request(h, http.MethodGet, "/", nil)
+			w := request(h, http.MethodGet, "/", nil)
+			Equal(t, w.Header().Get(StatusHeader), "MISS")
+			Equal(t, calls, 2)
+		})
+	}
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("0123456789"))
+	})
+	cache := lru.New[string, *Response](10).BuildThreadSafe()
+	h := Middleware(cache, Options{MaxBodySize: 5})(next)
+	_ = request(h, http.MethodGet, "/", nil)
+	w := request(h, http.MethodGet, "/", nil)
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	Equal(t, w.Body.String(), "0123456789")
+}
+
+func TestMiddlewareVary(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	})
+	cache := lru.New[string, *Response](10).BuildThreadSafe()
+	h := Middleware(cache, Options{DefaultMaxAge: time.Minute})(next)
+
+	w := request(h, http.MethodGet, "/", http.Header{"Accept-Language": {"en"}})
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	w = request(h, http.MethodGet, "/", http.Header{"Accept-Language": {"en"}})
+	Equal(t, w.Header().Get(StatusHeader), "HIT")
+	Equal(t, w.Body.String(), "en")
+	w = request(h, http.MethodGet, "/", http.Header{"Accept-Language": {"fr"}})
+	Equal(t, w.Header().Get(StatusHeader), "MISS")
+	Equal(t, w.Body.String(), "fr")
+	w = request(h, http.MethodGet, "/", http.Header{"Accept-Language": {"fr"}})
+	Equal(t, w.Header().Get(StatusHeader), "HIT")
+	Equal(t, w.Body.String(), "fr")
+}
+
+func TestMiddlewareExpiry(t *testing.T) {
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "public, s-maxage=0, max-age=60")
+	})
+	cache := lru.New[string, *Response](10).BuildThreadSafe()
+	h := Middleware(cache, Options{})(next)
+	_ = request(h, http.MethodGet, "/", nil)
+	_ = request(h, http.MethodGet, "/", nil)
+	Equal(t, calls, 2) // s-maxage takes precedence for a shared cache
+
+	next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	})
+	h = Middleware(cache, Options{})(next)
+	_ = request(h, http.MethodGet, "/b", nil)
+	w := request(h, http.MethodGet, "/b", nil)
+	Equal(t, w.Header().Get(StatusHeader), "HIT")
+	Equal(t, calls, 3)
+}
@@ -0,0 +1,201 @@
+package httpcache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport is an http.RoundTripper caching GET responses as a private cache following the RFC 9111 freshness
+// rules. Stale responses carrying an ETag or Last-Modified validator are kept and revalidated using If-None-Match
+// and If-Modified-Since, a 304 Not Modified refreshing the stored response.
+//
+// The StatusHeader is set to HIT, REVALIDATED or MISS on every response to a cacheable request.
+type Transport struct {
+	cache Cache
+	next  http.RoundTripper
+	opts  Options
+}
+
+// NewTransport returns a Transport caching responses from next, or http.DefaultTransport if nil, in the provided
+// cache.
+//
+// Options.DefaultMaxAge is used for responses with no explicit freshness information, falling back to 10% of the time
+// since their Last-Modified. Responses with validators are stored using the caches own MaxAge, so they can be
+// revalidated once stale, and so the cache should be built with a MaxAge.
+func NewTransport(cache Cache, next http.RoundTripper, opts Options) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	return &Transport{
+		cache: cache,
+		next:  next,
+		opts:  opts,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := http.MethodGet + " " + r.URL.String()
+
+	if r.Method != http.MethodGet {
+		res, err := t.next.RoundTrip(r)
+		if err == nil && r.Method != http.MethodHead && r.Method != http.MethodOptions &&
+			r.Method != http.MethodTrace && res.StatusCode < http.StatusBadRequest {
+			t.cache.Remove(key)
+		}
+		return res, err
+	}
+
+	reqCC := parseCacheControl(r.Header)
+	if reqCC.has("no-store") || r.Header.Get("Range") != "" ||
+		r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+		return t.next.RoundTrip(r)
+	}
+
+	resp, found := lookup(t.cache, key, r)
+	if !found {
+		return t.fetch(key, r)
+	}
+	if !reqCC.has("no-cache") && age(resp) < t.freshness(resp) {
+		return respond(r, resp, "HIT"), nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return t.fetch(key, r)
+	}
+	conditional := r.Clone(r.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+	res, err := t.next.RoundTrip(conditional)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusNotModified {
+		return t.store(key, r, res)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	refreshed := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       resp.Body,
+		Stored:     time.Now(),
+	}
+	for name, values := range res.Header {
+		if name != "Content-Length" {
+			refreshed.Header[name] = values
+		}
+	}
+	if maxAge, ok := t.storable(refreshed); ok {
+		store(t.cache, key, r, refreshed, maxAge)
+	}
+	return respond(r, refreshed, "REVALIDATED"), nil
+}
+
+// fetch sends the request, storing the response if permitted.
+func (t *Transport) fetch(key string, r *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(key, r, res)
+}
+
+// store reads and caches the response if permitted, returning it with its body restored.
+func (t *Transport) store(key string, r *http.Request, res *http.Response) (*http.Response, error) {
+	res.Header.Set(StatusHeader, "MISS")
+	if !cacheableStatus[res.StatusCode] || parseCacheControl(res.Header).has("no-store") {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, int64(t.opts.MaxBodySize)+1))
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	if len(body) > t.opts.MaxBodySize {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := res.Header.Clone()
+	header.Del(StatusHeader)
+	resp := &Response{
+		StatusCode: res.StatusCode,
+		Header:     header,
+		Body:       body,
+		Stored:     time.Now(),
+	}
+	if maxAge, ok := t.storable(resp); ok {
+		store(t.cache, key, r, resp, maxAge)
+	}
+	return res, nil
+}
+
+// storable returns how long to store the response and if it should be stored at all. Responses with validators are
+// stored using the caches own MaxAge so they can be revalidated once stale.
+func (t *Transport) storable(resp *Response) (time.Duration, bool) {
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" {
+		return 0, true
+	}
+	maxAge := t.freshness(resp)
+	return maxAge, maxAge > 0
+}
+
+// freshness returns the responses freshness lifetime.
+func (t *Transport) freshness(resp *Response) time.Duration {
+	if parseCacheControl(resp.Header).has("no-cache") {
+		return 0
+	}
+	if maxAge, found := lifetime(resp, false); found {
+		return maxAge
+	}
+	if t.opts.DefaultMaxAge > 0 {
+		return t.opts.DefaultMaxAge
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		return date(resp).Sub(lastModified) / 10
+	}
+	return 0
+}
+
+// age returns the current age of the response including any age it had when received.
+func age(resp *Response) time.Duration {
+	received, _ := strconv.ParseInt(resp.Header.Get("Age"), 10, 64)
+	return time.Duration(received)*time.Second + time.Since(resp.Stored)
+}
+
+// respond builds an http.Response for the request from the cached response.
+func respond(r *http.Request, resp *Response, status string) *http.Response {
+	header := resp.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(age(resp)/time.Second)))
+	header.Set(StatusHeader, status)
+	return &http.Response{
+		Status:        strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       r,
+	}
+}
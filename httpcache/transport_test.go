@@ -0,0 +1,125 @@
+package httpcache
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lru"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	res, err := client.Get(url)
+	Equal(t, err, nil)
+	b, err := io.ReadAll(res.Body)
+	Equal(t, err, nil)
+	_ = res.Body.Close()
+	return res, string(b)
+}
+
+func TestTransport(t *testing.T) {
+	var calls, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("fresh " + strconv.Itoa(calls)))
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte("etag " + strconv.Itoa(calls)))
+		default:
+			_, _ = w.Write([]byte("uncached " + strconv.Itoa(calls)))
+		}
+	}))
+	defer srv.Close()
+
+	cache := lru.New[string, *Response](1 << 20).MaxAge(time.Hour).Weigher(Weigh).BuildThreadSafe()
+	client := &http.Client{Transport: NewTransport(cache, nil, Options{})}
+
+	res, body := get(t, client, srv.URL+"/fresh")
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	Equal(t, body, "fresh 1")
+	res, body = get(t, client, srv.URL+"/fresh")
+	Equal(t, res.Header.Get(StatusHeader), "HIT")
+	Equal(t, res.StatusCode, http.StatusOK)
+	Equal(t, res.Header.Get("Age"), "0")
+	Equal(t, body, "fresh 1")
+	Equal(t, calls, 1)
+
+	res, body = get(t, client, srv.URL+"/etag")
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	Equal(t, body, "etag 2")
+	res, body = get(t, client, srv.URL+"/etag")
+	Equal(t, res.Header.Get(StatusHeader), "REVALIDATED")
+	Equal(t, res.StatusCode, http.StatusOK)
+	Equal(t, body, "etag 2")
+	Equal(t, notModified, 1)
+	Equal(t, calls, 3)
+
+	res, body = get(t, client, srv.URL+"/uncached")
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	res, body = get(t, client, srv.URL+"/uncached")
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	Equal(t, body, "uncached 5")
+
+	// unsafe methods invalidate
+	res, err := client.Post(srv.URL+"/fresh", "text/plain", nil)
+	Equal(t, err, nil)
+	_ = res.Body.Close()
+	res, body = get(t, client, srv.URL+"/fresh")
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	Equal(t, body, "fresh 7")
+}
+
+func TestTransportLastModified(t *testing.T) {
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+
+	cache := lru.New[string, *Response](10).MaxAge(time.Hour).BuildThreadSafe()
+	client := &http.Client{Transport: NewTransport(cache, nil, Options{})}
+
+	res, _ := get(t, client, srv.URL)
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	res, body := get(t, client, srv.URL)
+	Equal(t, res.Header.Get(StatusHeader), "REVALIDATED")
+	Equal(t, body, "body")
+	Equal(t, calls, 2)
+}
+
+func TestTransportMaxBodySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer srv.Close()
+
+	cache := lru.New[string, *Response](10).BuildThreadSafe()
+	client := &http.Client{Transport: NewTransport(cache, nil, Options{MaxBodySize: 5})}
+
+	_, body := get(t, client, srv.URL)
+	Equal(t, body, "0123456789")
+	res, body := get(t, client, srv.URL)
+	Equal(t, res.Header.Get(StatusHeader), "MISS")
+	Equal(t, body, "0123456789")
+}
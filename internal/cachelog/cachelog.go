@@ -0,0 +1,68 @@
+// Package cachelog emits the debug level records of the caches Logger builder functions, sampling the per entry
+// records so hot paths do not flood the logger.
+package cachelog
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Logger wraps an slog.Logger. Caches hold a nil *Logger when no logger is set, checking for nil before logging so
+// nothing, including boxing keys, is done.
+type Logger struct {
+	logger *slog.Logger
+	every  uint64
+	count  atomic.Uint64
+}
+
+// New returns a Logger logging every per entry record.
+func New(logger *slog.Logger) *Logger {
+	return &Logger{logger: logger, every: 1}
+}
+
+// SetSampling sets the Logger to log one in every n per entry records.
+func (l *Logger) SetSampling(n int) {
+	l.every = uint64(n)
+}
+
+// Enabled returns if the logger is set to log at debug level.
+func (l *Logger) Enabled() bool {
+	return l.logger != nil && l.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// Sample returns if the next per entry record should be logged.
+func (l *Logger) Sample() bool {
+	return l.Enabled() && (l.every == 1 || l.count.Add(1)%l.every == 1)
+}
+
+// Evicted logs an entry leaving the cache, the caller having checked Sample.
+func (l *Logger) Evicted(key any, reason string) {
+	attrs := []slog.Attr{slog.Any("key", key), slog.String("reason", reason)}
+	if l.every > 1 {
+		attrs = append(attrs, slog.Uint64("sampling", l.every))
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, "cache evicted", attrs...)
+}
+
+// Resized logs a change of capacity along with the number of entries evicted as a result.
+func (l *Logger) Resized(from, to, evicted int) {
+	if l.Enabled() {
+		l.logger.LogAttrs(context.Background(), slog.LevelDebug, "cache resized",
+			slog.Int("from", from), slog.Int("to", to), slog.Int("evicted", evicted))
+	}
+}
+
+// Cleared logs the cache being emptied along with the number of entries removed.
+func (l *Logger) Cleared(removed int) {
+	if l.Enabled() {
+		l.logger.LogAttrs(context.Background(), slog.LevelDebug, "cache cleared", slog.Int("removed", removed))
+	}
+}
+
+// LoadFailed logs an error loading keys from a backing store.
+func (l *Logger) LoadFailed(ctx context.Context, err error, attrs ...slog.Attr) {
+	if l.logger != nil && l.logger.Enabled(ctx, slog.LevelDebug) {
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "cache load failed", append(attrs, slog.Any("error", err))...)
+	}
+}
@@ -0,0 +1,28 @@
+package cachelog
+
+import (
+	"bytes"
+	. "github.com/go-playground/assert/v2"
+	"log/slog"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	Equal(t, l.Sample(), true)
+	Equal(t, l.Sample(), true)
+
+	l.SetSampling(2)
+	var sampled int
+	for i := 0; i < 10; i++ {
+		if l.Sample() {
+			sampled++
+		}
+	}
+	Equal(t, sampled, 5)
+
+	l = New(slog.New(slog.NewTextHandler(&buf, nil)))
+	Equal(t, l.Sample(), false)
+	Equal(t, New(nil).Enabled(), false)
+}
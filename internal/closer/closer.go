@@ -0,0 +1,23 @@
+// Package closer closes the values of entries leaving the caches when their CloseValues builder function is set.
+package closer
+
+import (
+	"io"
+	"reflect"
+)
+
+// Close closes the value if it implements io.Closer, passing any error returned to onError if not nil.
+func Close[K any](key K, value any, onError func(key K, err error)) {
+	if c, ok := value.(io.Closer); ok {
+		if err := c.Close(); err != nil && onError != nil {
+			onError(key, err)
+		}
+	}
+}
+
+// Same returns if a value being replaced is the same as its replacement, such as the same connection being set again,
+// in which case it must not be closed. Values which are not comparable are never the same.
+func Same(old, replacement any) bool {
+	v := reflect.ValueOf(old)
+	return v.IsValid() && v.Comparable() && old == replacement
+}
@@ -0,0 +1,51 @@
+package closer
+
+import (
+	"errors"
+	. "github.com/go-playground/assert/v2"
+	"testing"
+)
+
+type conn struct {
+	closed int
+	err    error
+}
+
+func (c *conn) Close() error {
+	c.closed++
+	return c.err
+}
+
+type closers []*conn
+
+func (c closers) Close() error { return nil }
+
+func TestClose(t *testing.T) {
+	var keys []string
+	onError := func(key string, err error) {
+		keys = append(keys, key+": "+err.Error())
+	}
+
+	c := &conn{}
+	Close("a", c, onError)
+	Equal(t, c.closed, 1)
+
+	c = &conn{err: errors.New("boom")}
+	Close("b", c, onError)
+	Close("c", c, nil)
+	Equal(t, c.closed, 2)
+	Equal(t, keys, []string{"b: boom"})
+
+	Close("d", 1, onError) // not a closer
+	Close[string]("e", nil, onError)
+	Equal(t, len(keys), 1)
+}
+
+func TestSame(t *testing.T) {
+	c := &conn{}
+	Equal(t, Same(c, c), true)
+	Equal(t, Same(c, &conn{}), false)
+	Equal(t, Same(1, 1), true)
+	Equal(t, Same(nil, nil), false)
+	Equal(t, Same(closers{c}, closers{c}), false)
+}
@@ -0,0 +1,37 @@
+// Package handle provides the Handle returned by the caches Acquire functions, shared by the lru and lfu packages.
+package handle
+
+import (
+	"sync"
+)
+
+// Handle is a reference to an entries value returned by Acquire which pins the entry, preventing it from being evicted
+// to remain within capacity and deferring the OnEvict function and CloseValues, should it leave the cache by other
+// means, until every handle to it has been released.
+type Handle[V any] struct {
+	value   V
+	release *release
+}
+
+type release struct {
+	once sync.Once
+	fn   func()
+}
+
+// New returns a Handle to the value calling fn, to unpin its entry, upon the first release.
+func New[V any](value V, fn func()) Handle[V] {
+	return Handle[V]{value: value, release: &release{fn: fn}}
+}
+
+// Value returns the entries value, which remains valid for use until the handle is released.
+func (h Handle[V]) Value() V {
+	return h.value
+}
+
+// Release releases the handle, unpinning the entry once every handle to it has been released. Releasing a handle more
+// than once, including via a copy, is a noop.
+func (h Handle[V]) Release() {
+	if h.release != nil {
+		h.release.once.Do(h.release.fn)
+	}
+}
@@ -0,0 +1,20 @@
+package handle
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	var released int
+	h := New("a", func() { released++ })
+	Equal(t, h.Value(), "a")
+
+	c := h
+	h.Release()
+	c.Release()
+	h.Release()
+	Equal(t, released, 1)
+
+	Handle[int]{}.Release() // noop
+}
@@ -0,0 +1,115 @@
+// Package stats accumulates the Stats deltas returned by the caches, whose types differ per package, into running
+// totals using reflection.
+package stats
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field is a single named statistic.
+type Field struct {
+	// Name is the Go field name, such as NegativeHits.
+	Name string
+
+	// Value is the formatted value.
+	Value string
+}
+
+// Totals accumulates Stats deltas. Unsigned fields are counters which are summed while all others are gauges, such
+// as Capacity and Len, which are replaced.
+type Totals[S any] struct {
+	mu    sync.Mutex
+	total S
+}
+
+// Add accumulates the delta into the totals and returns the totals fields in declaration order. Stats which are not
+// structs have no fields.
+func (t *Totals[S]) Add(delta S) []Field {
+	return Fields(t.Sum(delta))
+}
+
+// Sum accumulates the delta into the totals and returns them. Stats which are not structs are replaced.
+func (t *Totals[S]) Sum(delta S) S {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := reflect.ValueOf(&t.total).Elem()
+	if total.Kind() != reflect.Struct {
+		t.total = delta
+		return t.total
+	}
+	d := reflect.ValueOf(delta)
+	for i := 0; i < total.NumField(); i++ {
+		if !total.Type().Field(i).IsExported() {
+			continue
+		}
+		v := total.Field(i)
+		switch v.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(v.Uint() + d.Field(i).Uint())
+		default:
+			v.Set(d.Field(i))
+		}
+	}
+	return t.total
+}
+
+// Fields returns the exported fields of the Stats in declaration order. Stats which are not structs have no fields.
+func Fields(s any) (fields []Field) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() {
+			fields = append(fields, Field{Name: field.Name, Value: format(v.Field(i))})
+		}
+	}
+	return
+}
+
+func format(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return strings.ReplaceAll(fmt.Sprint(v.Interface()), " ", "_")
+	}
+}
+
+// Counter returns the named unsigned field of the Stats, zero if it has no such field.
+func Counter(s any, name string) uint64 {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+	switch f := v.FieldByName(name); f.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f.Uint()
+	default:
+		return 0
+	}
+}
+
+// SnakeCase converts a Go field name, such as NegativeHits, to snake case, negative_hits.
+func SnakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
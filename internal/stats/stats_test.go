@@ -0,0 +1,38 @@
+package stats
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lru"
+	"testing"
+)
+
+func TestTotals(t *testing.T) {
+	var totals Totals[lru.Stats]
+	fields := totals.Add(lru.Stats{Capacity: 10, Len: 2, Hits: 3})
+	Equal(t, fields[0], Field{Name: "Capacity", Value: "10"})
+	fields = totals.Add(lru.Stats{Capacity: 20, Len: 1, Hits: 4})
+	values := make(map[string]string)
+	for _, f := range fields {
+		values[f.Name] = f.Value
+	}
+	Equal(t, values["Capacity"], "20")
+	Equal(t, values["Len"], "1")
+	Equal(t, values["Hits"], "7")
+	Equal(t, totals.Sum(lru.Stats{Capacity: 20, Hits: 1}).Hits, uint(8))
+
+	var other Totals[int]
+	Equal(t, len(other.Add(1)), 0)
+	Equal(t, other.Sum(2), 2)
+}
+
+func TestCounter(t *testing.T) {
+	Equal(t, Counter(lru.Stats{Rejections: 2}, "Rejections"), uint64(2))
+	Equal(t, Counter(lru.Stats{Len: 2}, "Len"), uint64(0))
+	Equal(t, Counter(lru.Stats{}, "Missing"), uint64(0))
+	Equal(t, Counter(1, "Rejections"), uint64(0))
+}
+
+func TestSnakeCase(t *testing.T) {
+	Equal(t, SnakeCase("NegativeHits"), "negative_hits")
+	Equal(t, SnakeCase("Len"), "len")
+}
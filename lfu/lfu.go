@@ -1,24 +1,33 @@
 package lfu
 
 import (
+	"github.com/go-playground/cache/internal/cachelog"
+	"github.com/go-playground/cache/internal/closer"
+	"github.com/go-playground/cache/internal/handle"
 	listext "github.com/go-playground/pkg/v5/container/list"
 	syncext "github.com/go-playground/pkg/v5/sync"
 	timeext "github.com/go-playground/pkg/v5/time"
 	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/maphash"
+	"log/slog"
 	"time"
 )
 
 type builder[K comparable, V any] struct {
-	lfu *Cache[K, V]
+	lfu         *Cache[K, V]
+	logger      *slog.Logger
+	logSampling int
 }
 
 // New initializes a builder to create an LFU cache.
 func New[K comparable, V any](capacity int) *builder[K, V] {
 	return &builder[K, V]{
+		logSampling: 1,
 		lfu: &Cache[K, V]{
 			frequencies: listext.NewDoublyLinked[frequency[K, V]](),
 			entries:     make(map[K]*listext.Node[entry[K, V]]),
 			stats:       Stats{Capacity: capacity},
+			now:         timeext.NewInstant,
 		},
 	}
 }
@@ -34,10 +43,76 @@ func (b *builder[K, V]) MaxAge(maxAge time.Duration) *builder[K, V] {
 	return b
 }
 
+// Weigher sets a function returning the weight of each entry, such as its size in bytes, with the capacity then
+// bounding the total weight rather than the number of entries. Entries recorded using SetMissing weigh 1.
+//
+// The weight must be a positive value. An entry weighing more than the capacity is rejected rather than evicting every
+// other entry, any current entry for its key being removed, and counted as a Rejection in Stats.
+//
+// Default weighs every entry as 1.
+func (b *builder[K, V]) Weigher(fn func(key K, value V) int) *builder[K, V] {
+	b.lfu.weigher = fn
+	return b
+}
+
+// Logger sets a logger to emit debug level records for entries evicted to remain within capacity, expired or removed,
+// along with resizes and clears. Use logger.With to identify the cache. Nothing is logged when unset.
+//
+// Default is unset.
+func (b *builder[K, V]) Logger(logger *slog.Logger) *builder[K, V] {
+	b.logger = logger
+	return b
+}
+
+// LogSampling sets the Logger to log one in every n records for entries leaving the cache, so hot paths do not flood
+// the logger. Resizes and clears are always logged.
+//
+// Default is 1, logging every record.
+func (b *builder[K, V]) LogSampling(n int) *builder[K, V] {
+	if n <= 0 {
+		panic("LogSampling must be a positive value")
+	}
+	b.logSampling = n
+	return b
+}
+
+// Clock sets the function returning the current monotonic instant used to age entries, such as a fake clock in tests.
+//
+// Default is timeext.NewInstant.
+func (b *builder[K, V]) Clock(now func() timeext.Instant) *builder[K, V] {
+	b.lfu.now = now
+	return b
+}
+
+// CloseValues sets values implementing io.Closer to be closed whenever their entry leaves the cache for any reason,
+// including Clear, Resize and expiry, or has its value replaced by a different value. Errors returned by Close are
+// passed to onError, which may be nil to ignore them.
+//
+// Close is called after any OnEvict function while any lock guarding the cache is held and so must not call back
+// into the same cache. Use Acquire rather than Get to prevent a value still in use from being closed.
+func (b *builder[K, V]) CloseValues(onError func(key K, err error)) *builder[K, V] {
+	b.lfu.closeValues = true
+	b.lfu.onCloseError = onError
+	return b
+}
+
+// OnEvict sets a function to be called whenever an entry leaves the cache, or has its value replaced, along with the
+// Reason. Entries recorded using SetMissing hold no value and are not reported.
+//
+// The function is called while any lock guarding the cache is held and so must not call back into the same cache.
+func (b *builder[K, V]) OnEvict(fn func(key K, value V, reason Reason)) *builder[K, V] {
+	b.lfu.onEvict = fn
+	return b
+}
+
 // Build finalizes configuration and returns the LFU cache for use.
 func (b *builder[K, V]) Build() (lfu *Cache[K, V]) {
 	lfu = b.lfu
 	b.lfu = nil
+	if b.logger != nil {
+		lfu.logger = cachelog.New(b.logger)
+		lfu.logger.SetSampling(b.logSampling)
+	}
 	return
 }
 
@@ -48,6 +123,72 @@ func (b *builder[K, V]) BuildThreadSafe() ThreadSafeCache[K, V] {
 	}
 }
 
+// BuildSharded finalizes configuration and returns an LFU cache partitioned by key hash into the provided number of
+// shards, each with their own frequency lists guarded by their own lock. The capacity is split evenly across shards,
+// which must not outnumber it, and so with a Weigher an entry weighing more than capacity/shards is rejected.
+func (b *builder[K, V]) BuildSharded(shards int) *ShardedCache[K, V] {
+	if shards <= 0 {
+		panic("BuildSharded requires a positive number of shards")
+	}
+	if b.lfu.stats.Capacity < shards {
+		panic("BuildSharded requires a capacity of at least the number of shards")
+	}
+	lfu := b.Build()
+	sharded := &ShardedCache[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]syncext.RWMutex2[*Cache[K, V]], shards),
+		logger: lfu.logger,
+	}
+	for i, capacity := range splitCapacity(lfu.stats.Capacity, shards) {
+		sharded.shards[i] = syncext.NewRWMutex2(&Cache[K, V]{
+			frequencies:  listext.NewDoublyLinked[frequency[K, V]](),
+			entries:      make(map[K]*listext.Node[entry[K, V]]),
+			maxAge:       lfu.maxAge,
+			stats:        Stats{Capacity: capacity},
+			weigher:      lfu.weigher,
+			onEvict:      lfu.onEvict,
+			closeValues:  lfu.closeValues,
+			onCloseError: lfu.onCloseError,
+			logger:       lfu.logger,
+			now:          lfu.now,
+		})
+	}
+	return sharded
+}
+
+// Reason describes why an entry left the cache, or had its value replaced, when reported to the OnEvict function.
+type Reason uint8
+
+const (
+	// Capacity is when the entry was evicted to remain within capacity.
+	Capacity Reason = iota
+
+	// Expired is when the entry was discarded for exceeding its max age.
+	Expired
+
+	// Removed is when the entry was explicitly removed via Remove or Clear.
+	Removed
+
+	// Replaced is when the entries value was replaced by setting the same key.
+	Replaced
+)
+
+// String returns the lowercase name of the Reason.
+func (r Reason) String() string {
+	switch r {
+	case Capacity:
+		return "capacity"
+	case Expired:
+		return "expired"
+	case Removed:
+		return "removed"
+	case Replaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
 // Stats represents the cache statistics.
 type Stats struct {
 	// Capacity is the maximum cache capacity.
@@ -56,20 +197,77 @@ type Stats struct {
 	// Len is the current consumed cache capacity.
 	Len int
 
+	// Weight is the current total weight of all entries, equal to Len unless a Weigher is set.
+	Weight int
+
 	// Hits is the number of cache hits.
 	Hits uint
 
 	// Misses is the number of cache misses.
 	Misses uint
 
-	// Evictions is the number of cache evictions performed.
+	// Evictions is the number of cache evictions performed, the sum of CapacityEvictions and Expirations.
 	Evictions uint
 
+	// CapacityEvictions is the number of entries evicted to remain within capacity.
+	CapacityEvictions uint
+
+	// Expirations is the number of entries discarded for exceeding their max age.
+	Expirations uint
+
+	// Removals is the number of entries explicitly removed via Remove.
+	Removals uint
+
+	// Replacements is the number of sets which replaced an existing entry.
+	Replacements uint
+
 	// Gets is the number of cache gets performed regardless of a hit or miss.
 	Gets uint
 
 	// Sets is the number of cache sets performed.
 	Sets uint
+
+	// Rejections is the number of sets rejected for weighing more than the capacity, or replacing an entry with a
+	// heavier value which does not fit as the other entries are pinned.
+	Rejections uint
+
+	// NegativeHits is the number of cache gets which found a key recorded as known to be absent.
+	NegativeHits uint
+
+	// Pinned is the current number of entries with handles returned by Acquire not yet released, including those
+	// which have since left the cache.
+	Pinned int
+
+	// PinnedWeight is the current total weight of pinned entries.
+	PinnedWeight int
+}
+
+// Entry is a cache entries value along with its metadata.
+type Entry[V any] struct {
+	// Value is the cached value.
+	Value V
+
+	// Inserted is the instant the value was set, as returned by the caches Clock. Entries are only timestamped when a
+	// max age applies and so it is zero if it has no max age.
+	Inserted timeext.Instant
+
+	// Age is the duration since the value was set or zero if it has no max age.
+	Age time.Duration
+
+	// TTL is the remaining duration before the entry expires or zero if it has no max age.
+	TTL time.Duration
+
+	// Frequency is the number of times the entry has been accessed, the frequency tier it belongs to.
+	Frequency int
+}
+
+// KeyCount is a key along with its frequency as reported by TopK.
+type KeyCount[K comparable] struct {
+	// Key is the key.
+	Key K
+
+	// Count is the number of times the entry has been accessed, the frequency tier it belongs to.
+	Count int
 }
 
 type entry[K comparable, V any] struct {
@@ -77,6 +275,15 @@ type entry[K comparable, V any] struct {
 	value     V
 	frequency *listext.Node[frequency[K, V]]
 	timestamp timeext.Instant
+	maxAge    time.Duration
+	weight    int
+	missing   bool
+	// pins is the number of handles to the entry not yet released.
+	pins int
+	// reason and close are why the entry left the cache and if its value is to be closed upon finalization, deferred
+	// until released if pinned.
+	reason Reason
+	close  bool
 }
 
 type frequency[K comparable, V any] struct {
@@ -90,20 +297,90 @@ type Cache[K comparable, V any] struct {
 	entries     map[K]*listext.Node[entry[K, V]]
 	maxAge      time.Duration
 	stats       Stats
+	weight      int
+	weigher     func(key K, value V) int
+	onEvict     func(key K, value V, reason Reason)
+	// closeValues is if values implementing io.Closer are closed upon leaving the cache.
+	closeValues  bool
+	onCloseError func(key K, err error)
+	logger       *cachelog.Logger
+	now          func() timeext.Instant
+	// pinned and pinnedWeight are the number and total weight of entries with handles not yet released.
+	pinned       int
+	pinnedWeight int
 }
 
 // Set sets an item into the cache. It will replace the current entry if there is one.
 func (cache *Cache[K, V]) Set(key K, value V) {
+	cache.set(key, value, false, 0)
+}
+
+// SetWithMaxAge sets an item into the cache with its own maxAge overriding the caches MaxAge. It will replace the
+// current entry if there is one.
+func (cache *Cache[K, V]) SetWithMaxAge(key K, value V, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetWithMaxAge maxAge is not permitted to be a negative value")
+	}
+	cache.set(key, value, false, maxAge)
+}
+
+// SetMissing records the key as known to be absent, for example not found in the backing store, for the provided
+// maxAge which is usually shorter than the caches MaxAge. A maxAge of zero uses the caches MaxAge.
+//
+// Get will return None for the key while Lookup reports it as missing, allowing a caller to distinguish between a
+// known absent key and one not cached at all. It will replace the current entry if there is one.
+func (cache *Cache[K, V]) SetMissing(key K, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetMissing maxAge is not permitted to be a negative value")
+	}
+	var value V
+	cache.set(key, value, true, maxAge)
+}
+
+func (cache *Cache[K, V]) set(key K, value V, missing bool, maxAge time.Duration) {
 	cache.stats.Sets++
 
+	weight := cache.weigh(key, value, missing)
+	if weight > cache.stats.Capacity {
+		cache.stats.Rejections++
+		cache.Remove(key)
+		return
+	}
+
+	// only timestamped when aged, as getting the time on every set is comparatively expensive.
+	var timestamp timeext.Instant
+	if maxAge > 0 || cache.maxAge > 0 {
+		timestamp = cache.now()
+	}
+
 	node, found := cache.entries[key]
 	if found {
-		node.Value.value = value
-		if cache.maxAge > 0 {
-			node.Value.timestamp = timeext.NewInstant()
+		// make room before applying the new weight, pinning the entry meanwhile so it is not evicted itself.
+		node.Value.pins++
+		for cache.weight-node.Value.weight+weight > cache.stats.Capacity && cache.evict() {
+		}
+		node.Value.pins--
+		if weight > node.Value.weight && cache.weight-node.Value.weight+weight > cache.stats.Capacity {
+			cache.stats.Rejections++
+			cache.Remove(key)
+			return
 		}
+
+		cache.stats.Replacements++
+		cache.replaced(&node.Value, value)
+		if node.Value.pins > 0 {
+			node = cache.detach(node)
+		}
+		cache.weight += weight - node.Value.weight
+		node.Value.value = value
+		node.Value.missing = missing
+		node.Value.maxAge = maxAge
+		node.Value.weight = weight
+		node.Value.timestamp = timestamp
 		node.Value.frequency.Value.entries.MoveToFront(node)
 	} else {
+		for len(cache.entries) > 0 && cache.weight+weight > cache.stats.Capacity && cache.evict() {
+		}
 
 		// determine or create frequency
 		freq := cache.frequencies.Back()
@@ -117,41 +394,147 @@ func (cache *Cache[K, V]) Set(key K, value V) {
 			key:       key,
 			value:     value,
 			frequency: freq,
-		}
-		if cache.maxAge > 0 {
-			e.timestamp = timeext.NewInstant()
+			timestamp: timestamp,
+			maxAge:    maxAge,
+			weight:    weight,
+			missing:   missing,
 		}
 		cache.entries[key] = freq.Value.entries.PushFront(e)
-		if len(cache.entries) > cache.stats.Capacity {
-			// if we just added the back frequency node
-			if freq.Value.count == 1 && freq.Value.entries.Len() == 1 {
-				freq = freq.Prev()
-			}
-			if freq != nil {
-				ent := freq.Value.entries.PopBack()
-				ent.Value.frequency = nil // detach
-				delete(cache.entries, ent.Value.key)
-				if freq.Value.entries.Len() == 0 {
-					cache.frequencies.Remove(freq)
-				}
-				cache.stats.Evictions++
-			}
+		cache.weight += weight
+	}
+}
+
+// detach replaces the node of a pinned entry, whose value is being replaced, with a copy in the same position so the
+// value remains unchanged for the handles to it.
+func (cache *Cache[K, V]) detach(node *listext.Node[entry[K, V]]) *listext.Node[entry[K, V]] {
+	e := node.Value
+	e.pins = 0
+	entries := node.Value.frequency.Value.entries
+	replacement := entries.PushBefore(node, e)
+	entries.Remove(node)
+	node.Value.frequency = nil
+	cache.entries[e.key] = replacement
+	return replacement
+}
+
+// evict removes the least recently used entry not pinned from the least frequently used frequency, returning false if
+// every entry is pinned.
+func (cache *Cache[K, V]) evict() bool {
+	var freq *listext.Node[frequency[K, V]]
+	var ent *listext.Node[entry[K, V]]
+	for freq = cache.frequencies.Back(); freq != nil; freq = freq.Prev() {
+		for ent = freq.Value.entries.Back(); ent != nil && ent.Value.pins > 0; ent = ent.Prev() {
 		}
+		if ent != nil {
+			break
+		}
+	}
+	if freq == nil {
+		return false
+	}
+	freq.Value.entries.Remove(ent)
+	ent.Value.frequency = nil // detach
+	delete(cache.entries, ent.Value.key)
+	cache.weight -= ent.Value.weight
+	if freq.Value.entries.Len() == 0 {
+		cache.frequencies.Remove(freq)
+	}
+	cache.stats.Evictions++
+	cache.stats.CapacityEvictions++
+	cache.evicted(&ent.Value, Capacity)
+	cache.logEvicted(ent.Value.key, Capacity)
+	return true
+}
+
+// weigh returns the weight of an entry.
+func (cache *Cache[K, V]) weigh(key K, value V, missing bool) int {
+	if cache.weigher == nil || missing {
+		return 1
+	}
+	weight := cache.weigher(key, value)
+	if weight <= 0 {
+		panic("Weigher weight must be a positive value")
+	}
+	return weight
+}
+
+// evicted finalizes the entry leaving the cache, or having its value replaced, deferring until released if pinned.
+// Unless replaced, its value is closed if CloseValues is set.
+func (cache *Cache[K, V]) evicted(e *entry[K, V], reason Reason) {
+	if e.missing {
+		return
+	}
+	e.reason, e.close = reason, reason != Replaced
+	if e.pins == 0 {
+		cache.finalize(e)
+	}
+}
+
+// replaced finalizes the entry having its value replaced, closing the value if CloseValues is set and the replacement
+// is a different value.
+func (cache *Cache[K, V]) replaced(e *entry[K, V], value V) {
+	cache.evicted(e, Replaced)
+	if cache.closeValues && !e.missing && !closer.Same(e.value, value) {
+		if e.pins > 0 {
+			e.close = true
+		} else {
+			closer.Close(e.key, e.value, cache.onCloseError)
+		}
+	}
+}
+
+// finalize reports the entry to the OnEvict function if set and closes its value if it is to be closed.
+func (cache *Cache[K, V]) finalize(e *entry[K, V]) {
+	if cache.onEvict != nil {
+		cache.onEvict(e.key, e.value, e.reason)
+	}
+	if cache.closeValues && e.close {
+		closer.Close(e.key, e.value, cache.onCloseError)
+	}
+}
+
+// logEvicted logs the entry leaving the cache if a Logger is set and the record is sampled.
+func (cache *Cache[K, V]) logEvicted(key K, reason Reason) {
+	if cache.logger != nil && cache.logger.Sample() {
+		cache.logger.Evicted(key, reason.String())
 	}
 }
 
+// entryMaxAge returns the entries own maxAge, if set, otherwise the caches MaxAge.
+func (cache *Cache[K, V]) entryMaxAge(e *entry[K, V]) time.Duration {
+	if e.maxAge > 0 {
+		return e.maxAge
+	}
+	return cache.maxAge
+}
+
+// expired returns if the entry has outlived its max age.
+func (cache *Cache[K, V]) expired(e *entry[K, V]) bool {
+	maxAge := cache.entryMaxAge(e)
+	return maxAge > 0 && cache.now().Since(e.timestamp) > maxAge
+}
+
 // Get attempts to find an existing cache entry by key.
 // It returns an Option you must check before using the underlying value.
 func (cache *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
+	result, _ = cache.Lookup(key)
+	return
+}
+
+// Lookup attempts to find an existing cache entry by key the same as Get but additionally reports if the key was
+// recorded as known to be absent using SetMissing.
+func (cache *Cache[K, V]) Lookup(key K) (result optionext.Option[V], missing bool) {
 	cache.stats.Gets++
 
 	node, found := cache.entries[key]
 	if found {
-		if cache.maxAge > 0 && node.Value.timestamp.Elapsed() > cache.maxAge {
+		if cache.expired(&node.Value) {
 			cache.remove(node)
 			cache.stats.Evictions++
+			cache.stats.Expirations++
+			cache.evicted(&node.Value, Expired)
+			cache.logEvicted(key, Expired)
 		} else {
-			cache.stats.Hits++
 			nextCount := node.Value.frequency.Value.count + 1
 			// super edge case, int can wrap around, if that's the case don't do anything but
 			// mark as most recently accessed, it's already in the top tier and so want to keep it
@@ -179,7 +562,13 @@ func (cache *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
 					prev.Value.entries.InsertAtFront(node)
 				}
 			}
-			result = optionext.Some(node.Value.value)
+			if node.Value.missing {
+				missing = true
+				cache.stats.NegativeHits++
+			} else {
+				result = optionext.Some(node.Value.value)
+				cache.stats.Hits++
+			}
 		}
 	} else {
 		cache.stats.Misses++
@@ -187,15 +576,143 @@ func (cache *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
 	return
 }
 
+// Peek attempts to find an existing cache entry by key without counting it as an access, affecting its frequency or
+// recording stats. An entry past its MaxAge, or known to be absent, is reported as not found but is left for a following Get to remove.
+func (cache *Cache[K, V]) Peek(key K) (result optionext.Option[V]) {
+	node, found := cache.entries[key]
+	if found && !node.Value.missing && !cache.expired(&node.Value) {
+		result = optionext.Some(node.Value.value)
+	}
+	return
+}
+
+// Acquire attempts to find an existing cache entry by key the same as Get, returning a Handle to its value which pins
+// the entry until released. A pinned entry is not evicted to remain within capacity, which may temporarily be exceeded
+// if every entry is pinned, and should it leave the cache by other means its OnEvict function and CloseValues are
+// deferred until every handle to it is released.
+//
+// The handle must be released using the same locking as the cache.
+func (cache *Cache[K, V]) Acquire(key K) (Handle[V], bool) {
+	node, found := cache.acquire(key)
+	if !found {
+		return Handle[V]{}, false
+	}
+	return handle.New(node.Value.value, func() { cache.release(node) }), true
+}
+
+// acquire looks up the key the same as Get, pinning the entry if found.
+func (cache *Cache[K, V]) acquire(key K) (node *listext.Node[entry[K, V]], found bool) {
+	if result, _ := cache.Lookup(key); result.IsNone() {
+		return nil, false
+	}
+	node = cache.entries[key]
+	if node.Value.pins == 0 {
+		cache.pinned++
+		cache.pinnedWeight += node.Value.weight
+	}
+	node.Value.pins++
+	return node, true
+}
+
+// release releases a handle to the entry. Once no handles remain it is finalized if it has left the cache, otherwise
+// entries are evicted should the cache have exceeded capacity while it was pinned.
+func (cache *Cache[K, V]) release(node *listext.Node[entry[K, V]]) {
+	e := &node.Value
+	if e.pins--; e.pins > 0 {
+		return
+	}
+	cache.pinned--
+	cache.pinnedWeight -= e.weight
+	if e.frequency == nil {
+		cache.finalize(e)
+		return
+	}
+	for cache.weight > cache.stats.Capacity && cache.evict() {
+	}
+}
+
+// GetEntry attempts to find an existing cache entry by key returning its value along with metadata.
+// It does not count as an access, affect its frequency or record stats, making it suitable for debugging and
+// adaptive logic. Entries known to be absent or past their max age are reported as not found.
+func (cache *Cache[K, V]) GetEntry(key K) (result optionext.Option[Entry[V]]) {
+	node, found := cache.entries[key]
+	if !found || node.Value.missing || cache.expired(&node.Value) {
+		return
+	}
+	return optionext.Some(cache.entry(&node.Value))
+}
+
+// entry returns the entries value along with its metadata.
+func (cache *Cache[K, V]) entry(e *entry[K, V]) Entry[V] {
+	result := Entry[V]{
+		Value:     e.value,
+		Frequency: e.frequency.Value.count,
+	}
+	if maxAge := cache.entryMaxAge(e); maxAge > 0 {
+		result.Inserted = e.timestamp
+		result.Age = cache.now().Since(e.timestamp)
+		result.TTL = maxAge - result.Age
+	}
+	return result
+}
+
+// TopK returns up to n of the most frequently used keys in descending order of frequency, most recently used first
+// within the same frequency, read directly from the frequency tiers. It does not count as an access or record stats.
+// Entries known to be absent or past their max age are skipped.
+func (cache *Cache[K, V]) TopK(n int) (counts []KeyCount[K]) {
+	if n <= 0 {
+		panic("TopK n must be a positive value")
+	}
+	for freq := cache.frequencies.Front(); freq != nil; freq = freq.Next() {
+		for node := freq.Value.entries.Front(); node != nil; node = node.Next() {
+			if node.Value.missing || cache.expired(&node.Value) {
+				continue
+			}
+			counts = append(counts, KeyCount[K]{Key: node.Value.key, Count: freq.Value.count})
+			if len(counts) == n {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Range calls fn for each entry in descending order of frequency, most recently used first within the same frequency,
+// until fn returns false. It does not count as an access, affect its frequency or record stats. Entries known to be
+// absent or past their max age are skipped.
+//
+// fn must not modify the cache.
+func (cache *Cache[K, V]) Range(fn func(key K, entry Entry[V]) bool) {
+	for freq := cache.frequencies.Front(); freq != nil; freq = freq.Next() {
+		for node := freq.Value.entries.Front(); node != nil; node = node.Next() {
+			if node.Value.missing || cache.expired(&node.Value) {
+				continue
+			}
+			if !fn(node.Value.key, cache.entry(&node.Value)) {
+				return
+			}
+		}
+	}
+}
+
+// MaxAge returns the caches MaxAge, zero being no max age.
+func (cache *Cache[K, V]) MaxAge() time.Duration {
+	return cache.maxAge
+}
+
 // Remove removes the item matching the provided key from the cache, if not present is a noop.
 func (cache *Cache[K, V]) Remove(key K) {
 	if node, found := cache.entries[key]; found {
 		cache.remove(node)
+		cache.stats.Removals++
+		cache.evicted(&node.Value, Removed)
+		cache.logEvicted(key, Removed)
 	}
 }
 
 func (cache *Cache[K, V]) remove(node *listext.Node[entry[K, V]]) {
 	delete(cache.entries, node.Value.key)
+	cache.weight -= node.Value.weight
 	node.Value.frequency.Value.entries.Remove(node)
 	if node.Value.frequency.Value.entries.Len() == 0 {
 		cache.frequencies.Remove(node.Value.frequency)
@@ -203,23 +720,59 @@ func (cache *Cache[K, V]) remove(node *listext.Node[entry[K, V]]) {
 	node.Value.frequency = nil
 }
 
+// Capacity returns the maximum capacity of the cache.
+func (cache *Cache[K, V]) Capacity() int {
+	return cache.stats.Capacity
+}
+
+// Resize changes the maximum capacity of the cache. If the new capacity is lower than the current consumed capacity
+// the least frequently used entries are evicted immediately.
+func (cache *Cache[K, V]) Resize(capacity int) {
+	if capacity < 0 {
+		panic("Resize is not permitted to be a negative value")
+	}
+	from := cache.stats.Capacity
+	evicted := cache.resize(capacity)
+	if cache.logger != nil {
+		cache.logger.Resized(from, capacity, evicted)
+	}
+}
+
+// resize changes the maximum capacity of the cache returning the number of entries evicted.
+func (cache *Cache[K, V]) resize(capacity int) (evicted int) {
+	cache.stats.Capacity = capacity
+	for ; cache.weight > capacity && cache.evict(); evicted++ {
+	}
+	return
+}
+
 // Clear empties the cache.
 func (cache *Cache[K, V]) Clear() {
+	removed := cache.clear()
+	if cache.logger != nil {
+		cache.logger.Cleared(removed)
+	}
+}
+
+// clear empties the cache returning the number of entries removed.
+func (cache *Cache[K, V]) clear() (removed int) {
+	removed = len(cache.entries)
 	for _, node := range cache.entries {
 		cache.remove(node)
+		cache.evicted(&node.Value, Removed)
 	}
 	// resets/empties stats
 	_ = cache.Stats()
+	return
 }
 
 // Stats returns the delta of Stats since last call to the Stats function.
 func (cache *Cache[K, V]) Stats() (stats Stats) {
 	stats = cache.stats
 	stats.Len = len(cache.entries)
-	cache.stats.Hits = 0
-	cache.stats.Misses = 0
-	cache.stats.Evictions = 0
-	cache.stats.Gets = 0
-	cache.stats.Sets = 0
+	stats.Weight = cache.weight
+	stats.Pinned = cache.pinned
+	stats.PinnedWeight = cache.pinnedWeight
+	cache.stats = Stats{Capacity: cache.stats.Capacity}
 	return
 }
@@ -0,0 +1,211 @@
+package lfu
+
+import (
+	"cmp"
+	"github.com/go-playground/cache/internal/cachelog"
+	"github.com/go-playground/cache/internal/handle"
+	syncext "github.com/go-playground/pkg/v5/sync"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/maphash"
+	"slices"
+	"time"
+)
+
+// ShardedCache is a concurrent LFU cache which partitions entries and their frequency lists across multiple Caches by
+// key hash, each guarded by its own lock, reducing contention compared to ThreadSafeCache.
+//
+// Frequencies are tracked per shard and so eviction is least frequently used within a shard rather than globally.
+type ShardedCache[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []syncext.RWMutex2[*Cache[K, V]]
+	// logger is shared with the shards, which log entries leaving, while resizes and clears are logged once here.
+	logger *cachelog.Logger
+}
+
+func (c *ShardedCache[K, V]) shard(key K) syncext.RWMutex2[*Cache[K, V]] {
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+// Set sets an item into the cache. It will replace the current entry if there is one.
+func (c *ShardedCache[K, V]) Set(key K, value V) {
+	guard := c.shard(key).Lock()
+	guard.T.Set(key, value)
+	guard.Unlock()
+}
+
+// SetWithMaxAge sets an item into the cache with its own maxAge overriding the caches MaxAge. It will replace the
+// current entry if there is one.
+func (c *ShardedCache[K, V]) SetWithMaxAge(key K, value V, maxAge time.Duration) {
+	guard := c.shard(key).Lock()
+	guard.T.SetWithMaxAge(key, value, maxAge)
+	guard.Unlock()
+}
+
+// SetMissing records the key as known to be absent for the provided maxAge. See Cache.SetMissing.
+func (c *ShardedCache[K, V]) SetMissing(key K, maxAge time.Duration) {
+	guard := c.shard(key).Lock()
+	guard.T.SetMissing(key, maxAge)
+	guard.Unlock()
+}
+
+// Get attempts to find an existing cache entry by key.
+// It returns an Option you must check before using the underlying value.
+func (c *ShardedCache[K, V]) Get(key K) (result optionext.Option[V]) {
+	guard := c.shard(key).Lock()
+	result = guard.T.Get(key)
+	guard.Unlock()
+	return
+}
+
+// Lookup attempts to find an existing cache entry by key the same as Get but additionally reports if the key was
+// recorded as known to be absent using SetMissing.
+func (c *ShardedCache[K, V]) Lookup(key K) (result optionext.Option[V], missing bool) {
+	guard := c.shard(key).Lock()
+	result, missing = guard.T.Lookup(key)
+	guard.Unlock()
+	return
+}
+
+// Peek attempts to find an existing cache entry by key without counting it as an access, affecting its frequency or
+// recording stats. Only a read lock is taken on the owning shard.
+func (c *ShardedCache[K, V]) Peek(key K) (result optionext.Option[V]) {
+	guard := c.shard(key).RLock()
+	result = guard.T.Peek(key)
+	guard.RUnlock()
+	return
+}
+
+// GetEntry attempts to find an existing cache entry by key returning its value along with metadata without counting it
+// as an access. See Cache.GetEntry.
+func (c *ShardedCache[K, V]) GetEntry(key K) (result optionext.Option[Entry[V]]) {
+	guard := c.shard(key).RLock()
+	result = guard.T.GetEntry(key)
+	guard.RUnlock()
+	return
+}
+
+// Acquire attempts to find an existing cache entry by key the same as Get, returning a Handle to its value which pins
+// the entry until released. See Cache.Acquire.
+//
+// Releasing the handle locks the owning shard.
+func (c *ShardedCache[K, V]) Acquire(key K) (Handle[V], bool) {
+	shard := c.shard(key)
+	guard := shard.Lock()
+	node, found := guard.T.acquire(key)
+	guard.Unlock()
+	if !found {
+		return Handle[V]{}, false
+	}
+	return handle.New(node.Value.value, func() {
+		guard := shard.Lock()
+		guard.T.release(node)
+		guard.Unlock()
+	}), true
+}
+
+// TopK returns up to n of the most frequently used keys, merging the top n of every shard by frequency. See
+// Cache.TopK.
+func (c *ShardedCache[K, V]) TopK(n int) (counts []KeyCount[K]) {
+	if n <= 0 {
+		panic("TopK n must be a positive value")
+	}
+	for _, shard := range c.shards {
+		guard := shard.RLock()
+		counts = append(counts, guard.T.TopK(n)...)
+		guard.RUnlock()
+	}
+	slices.SortStableFunc(counts, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return
+}
+
+// Remove removes the item matching the provided key from the cache, if not present is a noop.
+func (c *ShardedCache[K, V]) Remove(key K) {
+	guard := c.shard(key).Lock()
+	guard.T.Remove(key)
+	guard.Unlock()
+}
+
+// Capacity returns the maximum capacity of the cache, the sum of each shards.
+func (c *ShardedCache[K, V]) Capacity() (capacity int) {
+	for _, shard := range c.shards {
+		guard := shard.RLock()
+		capacity += guard.T.Capacity()
+		guard.RUnlock()
+	}
+	return
+}
+
+// Resize changes the maximum capacity of the cache, split evenly across shards, evicting entries immediately if
+// shrinking below the current length.
+func (c *ShardedCache[K, V]) Resize(capacity int) {
+	if capacity < 0 {
+		panic("Resize is not permitted to be a negative value")
+	}
+	var from, evicted int
+	for i, capacity := range splitCapacity(capacity, len(c.shards)) {
+		guard := c.shards[i].Lock()
+		from += guard.T.stats.Capacity
+		evicted += guard.T.resize(capacity)
+		guard.Unlock()
+	}
+	if c.logger != nil {
+		c.logger.Resized(from, capacity, evicted)
+	}
+}
+
+// Clear empties the cache.
+func (c *ShardedCache[K, V]) Clear() {
+	var removed int
+	for _, shard := range c.shards {
+		guard := shard.Lock()
+		removed += guard.T.clear()
+		guard.Unlock()
+	}
+	if c.logger != nil {
+		c.logger.Cleared(removed)
+	}
+}
+
+// Stats returns the delta of Stats, merged across all shards, since last call to the Stats function.
+func (c *ShardedCache[K, V]) Stats() (stats Stats) {
+	for _, shard := range c.shards {
+		guard := shard.Lock()
+		s := guard.T.Stats()
+		guard.Unlock()
+
+		stats.Capacity += s.Capacity
+		stats.Len += s.Len
+		stats.Weight += s.Weight
+		stats.Pinned += s.Pinned
+		stats.PinnedWeight += s.PinnedWeight
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Evictions += s.Evictions
+		stats.CapacityEvictions += s.CapacityEvictions
+		stats.Expirations += s.Expirations
+		stats.Removals += s.Removals
+		stats.Replacements += s.Replacements
+		stats.Gets += s.Gets
+		stats.Sets += s.Sets
+		stats.Rejections += s.Rejections
+		stats.NegativeHits += s.NegativeHits
+	}
+	return
+}
+
+// splitCapacity divides capacity as evenly as possible across the number of shards.
+func splitCapacity(capacity, shards int) []int {
+	capacities := make([]int, shards)
+	for i := range capacities {
+		capacities[i] = capacity / shards
+		if i < capacity%shards {
+			capacities[i]++
+		}
+	}
+	return capacities
+}
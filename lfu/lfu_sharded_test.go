@@ -0,0 +1,199 @@
+package lfu
+
+import (
+	"bytes"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/cachetest"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLFUShardedBadConfig(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).BuildSharded(0)
+	}, "BuildSharded requires a positive number of shards")
+	PanicMatches(t, func() {
+		New[string, int](3).BuildSharded(4)
+	}, "BuildSharded requires a capacity of at least the number of shards")
+}
+
+func TestLFUShardedCache(t *testing.T) {
+	c := New[string, int](100).MaxAge(time.Hour).BuildSharded(4)
+	Equal(t, len(c.shards), 4)
+
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	Equal(t, c.Peek("1"), c.Get("1"))
+	Equal(t, c.Peek("missing"), optionext.None[int]())
+
+	Equal(t, c.GetEntry("1").Unwrap().Frequency, 2)
+
+	c.Remove("1")
+	Equal(t, c.Peek("1"), optionext.None[int]())
+	Equal(t, c.Get("1"), optionext.None[int]())
+
+	stats := c.Stats()
+	Equal(t, stats.Capacity, 100)
+	Equal(t, stats.Sets, uint(10))
+	Equal(t, stats.Gets, uint(2))
+	Equal(t, stats.Hits, uint(1))
+	Equal(t, stats.Misses, uint(1))
+	Equal(t, stats.Len, 9)
+
+	c.Resize(4)
+	stats = c.Stats()
+	Equal(t, stats.Capacity, 4)
+	Equal(t, stats.Len <= 4, true)
+
+	c.Clear()
+	stats = c.Stats()
+	Equal(t, stats.Capacity, 4)
+	Equal(t, stats.Len, 0)
+}
+
+func TestLFUPeek(t *testing.T) {
+	c := New[string, int](2).MaxAge(time.Nanosecond).Build()
+	c.Set("1", 1)
+	Equal(t, c.frequencies.Front().Value.count, 1)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Peek("1"), optionext.None[int]())
+	Equal(t, len(c.entries), 1)
+
+	c = New[string, int](2).Build()
+	c.Set("1", 1)
+	Equal(t, c.Peek("1"), optionext.Some(1))
+	Equal(t, c.frequencies.Front().Value.count, 1)
+	Equal(t, c.stats.Gets, uint(0))
+}
+
+func TestLFUShardedCacheConcurrency(t *testing.T) {
+	c := New[int, int](100).BuildSharded(8)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1_000; i++ {
+				c.Set(i, i)
+				if option := c.Peek(i); option.IsSome() && option.Unwrap() != i {
+					panic("undefined behaviour")
+				}
+				if option := c.Get(i); option.IsSome() && option.Unwrap() != i {
+					panic("undefined behaviour")
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	Equal(t, c.Stats().Len <= 100, true)
+}
+
+func BenchmarkLFUShardedCacheGetSetParallel(b *testing.B) {
+	cache := New[string, string](100).BuildSharded(16)
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			j := strconv.Itoa(i % 100)
+			cache.Set(j, "b")
+			option := cache.Get(j)
+			if option.IsNone() || option.Unwrap() != "b" {
+				panic("undefined behaviour")
+			}
+			i++
+		}
+	})
+}
+
+func TestLFUShardedCacheTopK(t *testing.T) {
+	c := New[int, int](100).BuildSharded(4)
+	for i := 0; i < 10; i++ {
+		c.Set(i, i)
+		for j := 0; j < i; j++ {
+			c.Get(i)
+		}
+	}
+	Equal(t, c.TopK(3), []KeyCount[int]{{Key: 9, Count: 10}, {Key: 8, Count: 9}, {Key: 7, Count: 8}})
+	Equal(t, len(c.TopK(100)), 10)
+}
+
+func TestLFUShardedCacheLogger(t *testing.T) {
+	var buf bytes.Buffer
+	c := New[int, int](80).Logger(newTestLogger(&buf)).BuildSharded(4)
+	for i := 0; i < 4; i++ {
+		c.Set(i, i)
+	}
+	c.Resize(40)
+	c.Clear()
+	Equal(t, buf.String(), `level=DEBUG msg="cache resized" from=80 to=40 evicted=0
+level=DEBUG msg="cache cleared" removed=4
+`)
+}
+
+func TestLFUShardedCacheCloseValues(t *testing.T) {
+	c := New[int, *conn](8).CloseValues(nil).BuildSharded(4)
+	conns := make([]*conn, 8)
+	for i := range conns {
+		conns[i] = &conn{name: strconv.Itoa(i)}
+		c.Set(i, conns[i])
+	}
+	c.Remove(0)
+	Equal(t, conns[0].closed, 1)
+	c.Clear()
+	for _, conn := range conns {
+		Equal(t, conn.closed, 1)
+	}
+}
+
+func TestLFUShardedCacheAcquire(t *testing.T) {
+	c := New[string, *conn](8).CloseValues(nil).BuildSharded(4)
+	_, found := c.Acquire("a")
+	Equal(t, found, false)
+
+	// values must never be closed while pinned and every value closed once it has left the cache.
+	var wg sync.WaitGroup
+	conns := make([][]*conn, 8)
+	for g := range conns {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1_000; i++ {
+				key := strconv.Itoa((i * (g + 1)) % 16)
+				value := &conn{name: key}
+				conns[g] = append(conns[g], value)
+				c.Set(key, value)
+				if h, found := c.Acquire(key); found {
+					runtime.Gosched() // while in use
+					if h.Value().closed != 0 {
+						t.Errorf("%s closed while pinned", key)
+					}
+					h.Release()
+				}
+				if i%100 == 0 {
+					c.Remove(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Clear()
+	stats := c.Stats()
+	Equal(t, stats.Pinned, 0)
+	Equal(t, stats.PinnedWeight, 0)
+	for _, values := range conns {
+		for _, value := range values {
+			Equal(t, value.closed, 1)
+		}
+	}
+}
+
+func TestLFUShardedCacheConformance(t *testing.T) {
+	cachetest.RunConformance(t, func(cfg cachetest.Config) cachetest.Cache[Stats] {
+		return New[int, int](cfg.Capacity).MaxAge(cfg.MaxAge).Clock(cfg.Clock).BuildSharded(4)
+	}, cachetest.Options{Policy: cachetest.Any})
+}
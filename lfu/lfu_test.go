@@ -1,9 +1,13 @@
 package lfu
 
 import (
+	"bytes"
+	"errors"
 	. "github.com/go-playground/assert/v2"
 	syncext "github.com/go-playground/pkg/v5/sync"
+	timeext "github.com/go-playground/pkg/v5/time"
 	optionext "github.com/go-playground/pkg/v5/values/option"
+	"log/slog"
 	"strconv"
 	"testing"
 	"time"
@@ -40,6 +44,10 @@ func TestLFUBasics(t *testing.T) {
 	Equal(t, stats.Gets, uint(5))
 	Equal(t, stats.Sets, uint(5))
 	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(1))
+	Equal(t, stats.Expirations, uint(0))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.Replacements, uint(1))
 	Equal(t, stats.Len, 2)
 	Equal(t, stats.Capacity, 3)
 
@@ -55,6 +63,7 @@ func TestLFUBasics(t *testing.T) {
 	Equal(t, stats.Gets, uint(0))
 	Equal(t, stats.Sets, uint(0))
 	Equal(t, stats.Evictions, uint(0))
+	Equal(t, stats.Removals, uint(0))
 	Equal(t, stats.Len, 0)
 	Equal(t, stats.Capacity, 3)
 }
@@ -68,6 +77,8 @@ func TestLFUMaxAge(t *testing.T) {
 	Equal(t, c.Get("1"), optionext.None[int]())
 	Equal(t, len(c.entries), 0)
 	Equal(t, c.stats.Evictions, uint(1))
+	Equal(t, c.stats.Expirations, uint(1))
+	Equal(t, c.stats.CapacityEvictions, uint(0))
 }
 
 func TestLFUEdgeFrequencySplitAndRecombine(t *testing.T) {
@@ -181,6 +192,481 @@ func TestLFULFU(t *testing.T) {
 	Equal(t, c.Get("3"), optionext.Some(3))
 }
 
+func TestLFUResize(t *testing.T) {
+	c := New[string, int](4).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.Set("4", 4)
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("4"), optionext.Some(4))
+
+	c.Resize(2)
+	Equal(t, c.stats.Capacity, 2)
+	Equal(t, len(c.entries), 2)
+	Equal(t, c.stats.Evictions, uint(2))
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("3"), optionext.None[int]())
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("4"), optionext.Some(4))
+
+	c.Resize(0)
+	Equal(t, len(c.entries), 0)
+	Equal(t, c.frequencies.Len(), 0)
+
+	PanicMatches(t, func() {
+		c.Resize(-1)
+	}, "Resize is not permitted to be a negative value")
+}
+
+func TestLFUSetMissing(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.SetMissing("1", time.Nanosecond)
+	c.SetMissing("2", 0)
+	c.Set("3", 3)
+
+	result, missing := c.Lookup("2")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, true)
+	Equal(t, c.Get("2"), optionext.None[int]())
+
+	result, missing = c.Lookup("3")
+	Equal(t, result, optionext.Some(3))
+	Equal(t, missing, false)
+
+	result, missing = c.Lookup("4")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, false)
+
+	// negative entries use their own maxAge
+	time.Sleep(time.Second) // for windows :(
+	result, missing = c.Lookup("1")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, false)
+
+	// replacing a negative entry
+	c.Set("2", 2)
+	result, missing = c.Lookup("2")
+	Equal(t, result, optionext.Some(2))
+	Equal(t, missing, false)
+
+	stats := c.Stats()
+	Equal(t, stats.Sets, uint(4))
+	Equal(t, stats.Gets, uint(6))
+	Equal(t, stats.Hits, uint(2))
+	Equal(t, stats.NegativeHits, uint(2))
+	Equal(t, stats.Misses, uint(1))
+	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.Len, 2)
+
+	PanicMatches(t, func() {
+		c.SetMissing("1", -time.Second)
+	}, "SetMissing maxAge is not permitted to be a negative value")
+}
+
+func TestLFUGetEntry(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.Set("1", 1)
+	c.SetMissing("2", time.Minute)
+
+	option := c.GetEntry("1")
+	Equal(t, option.IsSome(), true)
+	e := option.Unwrap()
+	Equal(t, e.Value, 1)
+	Equal(t, e.Frequency, 1)
+	Equal(t, e.Age < time.Minute, true)
+	Equal(t, e.TTL > 59*time.Minute && e.TTL <= time.Hour, true)
+
+	// GetEntry is not an access
+	Equal(t, c.GetEntry("1").Unwrap().Frequency, 1)
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.GetEntry("1").Unwrap().Frequency, 3)
+
+	Equal(t, c.GetEntry("2"), optionext.None[Entry[int]]())
+	Equal(t, c.GetEntry("3"), optionext.None[Entry[int]]())
+
+	stats := c.Stats()
+	Equal(t, stats.Gets, uint(2))
+
+	now := timeext.Instant(1)
+	c = New[string, int](3).MaxAge(time.Hour).Clock(func() timeext.Instant { return now }).Build()
+	c.Set("1", 1)
+	now += timeext.Instant(time.Minute)
+	e = c.GetEntry("1").Unwrap()
+	Equal(t, e.Inserted, timeext.Instant(1))
+	Equal(t, e.Age, time.Minute)
+	Equal(t, e.TTL, 59*time.Minute)
+
+	// only aged with a max age
+	var calls int
+	c = New[string, int](3).Clock(func() timeext.Instant {
+		calls++
+		return now
+	}).Build()
+	c.Set("1", 1)
+	e = c.GetEntry("1").Unwrap()
+	Equal(t, calls, 0)
+	Equal(t, e.Inserted, timeext.Instant(0))
+	Equal(t, e.Age, time.Duration(0))
+	Equal(t, e.TTL, time.Duration(0))
+}
+
+func TestLFUOnEvict(t *testing.T) {
+	type evicted struct {
+		key    string
+		value  int
+		reason Reason
+	}
+	var evictions []evicted
+
+	c := New[string, int](2).MaxAge(time.Hour).OnEvict(func(key string, value int, reason Reason) {
+		evictions = append(evictions, evicted{key: key, value: value, reason: reason})
+	}).Build()
+	c.Set("1", 1)
+	c.Set("1", 10)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.SetMissing("4", time.Nanosecond)
+	c.Remove("3")
+	c.Set("5", 5)
+	c.SetMissing("5", time.Nanosecond)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("5"), optionext.None[int]())
+	c.Set("6", 6)
+	c.Clear()
+
+	c = New[string, int](2).MaxAge(time.Nanosecond).OnEvict(func(key string, value int, reason Reason) {
+		evictions = append(evictions, evicted{key: key, value: value, reason: reason})
+	}).Build()
+	c.Set("7", 7)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("7"), optionext.None[int]())
+
+	Equal(t, evictions, []evicted{
+		{key: "1", value: 1, reason: Replaced},
+		{key: "1", value: 10, reason: Capacity},
+		{key: "2", value: 2, reason: Capacity},
+		{key: "3", value: 3, reason: Removed},
+		{key: "5", value: 5, reason: Replaced},
+		{key: "6", value: 6, reason: Removed},
+		{key: "7", value: 7, reason: Expired},
+	})
+	Equal(t, Capacity.String(), "capacity")
+	Equal(t, Expired.String(), "expired")
+}
+
+// conn is a value implementing io.Closer, failing to close when bad.
+type conn struct {
+	name   string
+	bad    bool
+	closed int
+}
+
+func (c *conn) Close() error {
+	c.closed++
+	if c.bad {
+		return errors.New(c.name + " failed to close")
+	}
+	return nil
+}
+
+func TestLFUCloseValues(t *testing.T) {
+	var now timeext.Instant
+	var errs []string
+	c := New[string, *conn](2).MaxAge(time.Minute).Clock(func() timeext.Instant { return now }).
+		CloseValues(func(key string, err error) {
+			errs = append(errs, key+": "+err.Error())
+		}).Build()
+
+	a, b, d, e := &conn{name: "a"}, &conn{name: "b", bad: true}, &conn{name: "d"}, &conn{name: "e"}
+	c.Set("a", a)
+	c.Set("a", a) // same value is not closed
+	Equal(t, a.closed, 0)
+	c.Set("b", b)
+	c.Set("a", d) // replaced
+	Equal(t, a.closed, 1)
+	c.Set("e", e) // evicts b
+	Equal(t, b.closed, 1)
+	Equal(t, errs, []string{"b: b failed to close"})
+
+	c.Remove("a")
+	Equal(t, d.closed, 1)
+	now = timeext.Instant(2 * time.Minute)
+	Equal(t, c.Get("e").IsNone(), true) // expired
+	Equal(t, e.closed, 1)
+
+	f, g := &conn{name: "f"}, &conn{name: "g"}
+	c.Set("f", f)
+	c.Set("g", g)
+	c.SetMissing("g", 0) // replaced by no value
+	Equal(t, g.closed, 1)
+	c.Resize(1)
+	c.Clear()
+	Equal(t, f.closed, 1)
+	Equal(t, []int{a.closed, b.closed, d.closed, e.closed, f.closed, g.closed}, []int{1, 1, 1, 1, 1, 1})
+
+	// values not implementing io.Closer are ignored.
+	New[string, int](1).CloseValues(nil).Build().Set("1", 1)
+}
+
+func TestLFUAcquire(t *testing.T) {
+	var now timeext.Instant
+	var evictions []string
+	c := New[string, *conn](2).MaxAge(time.Minute).Clock(func() timeext.Instant { return now }).
+		OnEvict(func(key string, value *conn, reason Reason) {
+			evictions = append(evictions, key+" "+reason.String())
+		}).CloseValues(nil).Build()
+	a, b, d, e := &conn{name: "a"}, &conn{name: "b"}, &conn{name: "d"}, &conn{name: "e"}
+	c.Set("a", a)
+	c.Set("b", b)
+
+	h, found := c.Acquire("a")
+	Equal(t, found, true)
+	Equal(t, h.Value(), a)
+	_, found = c.Acquire("z")
+	Equal(t, found, false)
+	c.Get("b")
+	c.Get("b")    // a is now the least frequently used
+	c.Set("d", d) // evicts b rather than the pinned a
+	Equal(t, b.closed, 1)
+	stats := c.Stats()
+	Equal(t, stats.Len, 2)
+	Equal(t, stats.Pinned, 1)
+	Equal(t, stats.PinnedWeight, 1)
+
+	// capacity is exceeded while every entry is pinned, until released.
+	h2, _ := c.Acquire("d")
+	c.Resize(1)
+	Equal(t, c.Stats().Len, 2)
+	h2.Release()
+	h2.Release() // noop
+	Equal(t, d.closed, 1)
+	stats = c.Stats()
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Pinned, 1)
+	c.Resize(2)
+	c.Set("e", e)
+
+	// replaced while pinned keeps its value until released.
+	a2 := &conn{name: "a2"}
+	c.Set("a", a2)
+	Equal(t, h.Value(), a)
+	Equal(t, c.Get("a").Unwrap(), a2)
+	Equal(t, a.closed, 0)
+	h.Release()
+	Equal(t, a.closed, 1)
+
+	// removed while pinned is finalized upon release.
+	h, _ = c.Acquire("e")
+	c.Remove("e")
+	Equal(t, c.Get("e").IsNone(), true)
+	Equal(t, e.closed, 0)
+	Equal(t, c.Stats().Pinned, 1)
+	h.Release()
+	Equal(t, e.closed, 1)
+
+	// as is expired or cleared.
+	h, _ = c.Acquire("a")
+	h2 = h
+	now = timeext.Instant(2 * time.Minute)
+	Equal(t, c.Get("a").IsNone(), true)
+	c.Clear()
+	Equal(t, a2.closed, 0)
+	h2.Release()
+	h.Release() // noop, released via copy
+	Equal(t, a2.closed, 1)
+
+	Equal(t, evictions, []string{"b capacity", "d capacity", "a replaced", "e removed", "a expired"})
+	stats = c.Stats()
+	Equal(t, stats.Len, 0)
+	Equal(t, stats.Pinned, 0)
+	Equal(t, stats.PinnedWeight, 0)
+	Handle[int]{}.Release() // noop
+}
+
+func TestLFUWeigher(t *testing.T) {
+	c := New[string, string](10).Weigher(func(key string, value string) int {
+		return len(value)
+	}).Build()
+	c.Set("1", "aaaa")
+	c.Set("2", "bbbb")
+	Equal(t, c.weight, 8)
+	c.Set("3", "cc") // within capacity
+	Equal(t, len(c.entries), 3)
+	c.Set("4", "d") // evicts 1
+	Equal(t, c.Get("1"), optionext.None[string]())
+	Equal(t, c.weight, 7)
+
+	// replacing with a heavier value
+	c.Set("4", "dddddd") // evicts 2
+	Equal(t, c.Get("2"), optionext.None[string]())
+	Equal(t, c.Get("4"), optionext.Some("dddddd"))
+	c.SetMissing("5", time.Hour)
+	Equal(t, c.weight, 9)
+
+	c.Resize(7)
+	stats := c.Stats()
+	Equal(t, stats.Weight <= 7, true)
+	Equal(t, stats.CapacityEvictions, uint(3))
+
+	c.Remove("4")
+	c.Clear()
+	Equal(t, c.Stats().Weight, 0)
+
+	// heavier than the capacity
+	c.Set("6", "fff")
+	c.Set("7", "ggg")
+	c.Set("6", "hhhhhhhh") // rejected, removing 6
+	Equal(t, c.Get("6"), optionext.None[string]())
+	Equal(t, c.Get("7"), optionext.Some("ggg"))
+	stats = c.Stats()
+	Equal(t, stats.Weight, 3)
+	Equal(t, stats.Rejections, uint(1))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(0))
+
+	PanicMatches(t, func() {
+		c.Set("8", "")
+	}, "Weigher weight must be a positive value")
+}
+
+func TestLFUWeigherReplacement(t *testing.T) {
+	c := New[string, string](10).Weigher(func(key string, value string) int {
+		return len(value)
+	}).Build()
+	c.Set("1", "aaaa")
+	c.Set("2", "bbbb")
+	c.Get("2")
+
+	// a heavier replacement evicts other entries, even more frequently used, rather than itself.
+	c.Set("1", "aaaaaaaa")
+	Equal(t, c.Get("1"), optionext.Some("aaaaaaaa"))
+	Equal(t, c.Get("2"), optionext.None[string]())
+	stats := c.Stats()
+	Equal(t, stats.Weight, 8)
+	Equal(t, stats.CapacityEvictions, uint(1))
+	Equal(t, stats.Replacements, uint(1))
+
+	// rejected when the other entries are pinned.
+	c.Set("2", "bb")
+	h, _ := c.Acquire("2")
+	c.Set("1", "aaaaaaaaa")
+	Equal(t, c.Get("1"), optionext.None[string]())
+	Equal(t, c.Get("2"), optionext.Some("bb"))
+	h.Release()
+	stats = c.Stats()
+	Equal(t, stats.Weight, 2)
+	Equal(t, stats.Rejections, uint(1))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.Replacements, uint(0))
+}
+
+func TestLFUTopK(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).Build().TopK(0)
+	}, "TopK n must be a positive value")
+
+	c := New[string, int](5).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.SetMissing("4", 0)
+	c.SetWithMaxAge("5", 5, time.Nanosecond)
+	for i := 0; i < 3; i++ {
+		c.Get("2")
+		c.Get("4")
+		c.Get("5")
+	}
+	c.Get("3")
+	time.Sleep(time.Second) // for windows :(
+
+	Equal(t, c.TopK(10), []KeyCount[string]{{Key: "2", Count: 4}, {Key: "3", Count: 2}, {Key: "1", Count: 1}})
+	Equal(t, c.TopK(2), []KeyCount[string]{{Key: "2", Count: 4}, {Key: "3", Count: 2}})
+
+	// does not count as an access
+	stats := c.Stats()
+	Equal(t, stats.Gets, uint(10))
+	Equal(t, c.GetEntry("2").Unwrap().Frequency, 4)
+}
+
+func TestLFURange(t *testing.T) {
+	c := New[string, int](5).MaxAge(time.Hour).Build()
+	Equal(t, c.MaxAge(), time.Hour)
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.SetMissing("3", 0)
+	c.SetWithMaxAge("4", 4, time.Nanosecond)
+	c.Get("1")
+	time.Sleep(time.Second) // for windows :(
+
+	var keys []string
+	var frequencies []int
+	c.Range(func(key string, entry Entry[int]) bool {
+		keys = append(keys, key)
+		frequencies = append(frequencies, entry.Frequency)
+		return true
+	})
+	Equal(t, keys, []string{"1", "2"})
+	Equal(t, frequencies, []int{2, 1})
+
+	keys = nil
+	c.Range(func(key string, entry Entry[int]) bool {
+		keys = append(keys, key)
+		return false
+	})
+	Equal(t, keys, []string{"1"})
+	Equal(t, c.Stats().Gets, uint(1))
+}
+
+func TestLFULogger(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).LogSampling(0)
+	}, "LogSampling must be a positive value")
+
+	var buf bytes.Buffer
+	c := New[string, int](2).Logger(newTestLogger(&buf)).Build()
+	c.Set("1", 1)
+	c.Get("1")
+	c.Set("2", 2)
+	c.Set("3", 3) // evicts 2 as least frequently used
+	c.SetWithMaxAge("3", 3, time.Nanosecond)
+	time.Sleep(time.Second) // for windows :(
+	c.Get("3")
+	c.Set("4", 4)
+	c.Remove("4")
+	c.Resize(0)
+	c.Clear()
+	Equal(t, buf.String(), `level=DEBUG msg="cache evicted" key=2 reason=capacity
+level=DEBUG msg="cache evicted" key=3 reason=expired
+level=DEBUG msg="cache evicted" key=4 reason=removed
+level=DEBUG msg="cache evicted" key=1 reason=capacity
+level=DEBUG msg="cache resized" from=2 to=0 evicted=1
+level=DEBUG msg="cache cleared" removed=0
+`)
+}
+
+func TestLFUSetWithMaxAge(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.SetWithMaxAge("1", 1, time.Nanosecond)
+	c.Set("2", 2)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, c.Get("2"), optionext.Some(2))
+	Equal(t, c.stats.Expirations, uint(1))
+
+	// replacing resets to the caches MaxAge
+	c.SetWithMaxAge("2", 2, time.Minute)
+	Equal(t, c.GetEntry("2").Unwrap().TTL <= time.Minute, true)
+	c.Set("2", 2)
+	Equal(t, c.GetEntry("2").Unwrap().TTL > time.Minute, true)
+
+	PanicMatches(t, func() {
+		c.SetWithMaxAge("1", 1, -time.Second)
+	}, "SetWithMaxAge maxAge is not permitted to be a negative value")
+}
+
 func BenchmarkLFUCacheWithMaxAge(b *testing.B) {
 	cache := New[string, string](100).MaxAge(time.Second).Build()
 
@@ -253,3 +739,16 @@ func BenchmarkLFUCacheGetSetParallel(b *testing.B) {
 		}
 	})
 }
+
+// newTestLogger returns a debug level logger writing records without their time to buf.
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
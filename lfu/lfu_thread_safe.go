@@ -1,9 +1,11 @@
 package lfu
 
 import (
+	"github.com/go-playground/cache/internal/handle"
 	syncext "github.com/go-playground/pkg/v5/sync"
 	optionext "github.com/go-playground/pkg/v5/values/option"
 	"sync"
+	"time"
 )
 
 // ThreadSafeCache is a drop in replacement for Cache which automatically handles locking all cache interactions.
@@ -19,6 +21,21 @@ func (c ThreadSafeCache[K, V]) Set(key K, value V) {
 	guard.Unlock()
 }
 
+// SetWithMaxAge sets an item into the cache with its own maxAge overriding the caches MaxAge. It will replace the
+// current entry if there is one.
+func (c ThreadSafeCache[K, V]) SetWithMaxAge(key K, value V, maxAge time.Duration) {
+	guard := c.cache.Lock()
+	guard.T.SetWithMaxAge(key, value, maxAge)
+	guard.Unlock()
+}
+
+// SetMissing records the key as known to be absent for the provided maxAge. See Cache.SetMissing.
+func (c ThreadSafeCache[K, V]) SetMissing(key K, maxAge time.Duration) {
+	guard := c.cache.Lock()
+	guard.T.SetMissing(key, maxAge)
+	guard.Unlock()
+}
+
 // Get attempts to find an existing cache entry by key.
 // It returns an Option you must check before using the underlying value.
 func (c ThreadSafeCache[K, V]) Get(key K) (result optionext.Option[V]) {
@@ -28,6 +45,50 @@ func (c ThreadSafeCache[K, V]) Get(key K) (result optionext.Option[V]) {
 	return
 }
 
+// Lookup attempts to find an existing cache entry by key the same as Get but additionally reports if the key was
+// recorded as known to be absent using SetMissing.
+func (c ThreadSafeCache[K, V]) Lookup(key K) (result optionext.Option[V], missing bool) {
+	guard := c.cache.Lock()
+	result, missing = guard.T.Lookup(key)
+	guard.Unlock()
+	return
+}
+
+// GetEntry attempts to find an existing cache entry by key returning its value along with metadata without counting it
+// as an access. See Cache.GetEntry.
+func (c ThreadSafeCache[K, V]) GetEntry(key K) (result optionext.Option[Entry[V]]) {
+	guard := c.cache.Lock()
+	result = guard.T.GetEntry(key)
+	guard.Unlock()
+	return
+}
+
+// Acquire attempts to find an existing cache entry by key the same as Get, returning a Handle to its value which pins
+// the entry until released. See Cache.Acquire.
+//
+// Releasing the handle locks the cache.
+func (c ThreadSafeCache[K, V]) Acquire(key K) (Handle[V], bool) {
+	guard := c.cache.Lock()
+	node, found := guard.T.acquire(key)
+	guard.Unlock()
+	if !found {
+		return Handle[V]{}, false
+	}
+	return handle.New(node.Value.value, func() {
+		guard := c.cache.Lock()
+		guard.T.release(node)
+		guard.Unlock()
+	}), true
+}
+
+// TopK returns up to n of the most frequently used keys. See Cache.TopK.
+func (c ThreadSafeCache[K, V]) TopK(n int) (counts []KeyCount[K]) {
+	guard := c.cache.Lock()
+	counts = guard.T.TopK(n)
+	guard.Unlock()
+	return
+}
+
 // Remove removes the item matching the provided key from the cache, if not present is a noop.
 func (c ThreadSafeCache[K, V]) Remove(key K) {
 	guard := c.cache.Lock()
@@ -35,6 +96,21 @@ func (c ThreadSafeCache[K, V]) Remove(key K) {
 	guard.Unlock()
 }
 
+// Capacity returns the maximum capacity of the cache.
+func (c ThreadSafeCache[K, V]) Capacity() (capacity int) {
+	guard := c.cache.Lock()
+	capacity = guard.T.Capacity()
+	guard.Unlock()
+	return
+}
+
+// Resize changes the maximum capacity of the cache, evicting entries immediately if shrinking below the current length.
+func (c ThreadSafeCache[K, V]) Resize(capacity int) {
+	guard := c.cache.Lock()
+	guard.T.Resize(capacity)
+	guard.Unlock()
+}
+
 // Clear empties the cache.
 func (c ThreadSafeCache[K, V]) Clear() {
 	guard := c.cache.Lock()
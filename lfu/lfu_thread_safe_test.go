@@ -2,7 +2,11 @@ package lfu
 
 import (
 	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/cachetest"
 	optionext "github.com/go-playground/pkg/v5/values/option"
+	"runtime"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -27,6 +31,9 @@ func TestLFUThreadSafeCache(t *testing.T) {
 
 	c.Clear()
 	Equal(t, c.Get("1"), optionext.None[int]())
+	stats = c.Stats()
+	Equal(t, stats.Removals, uint(0))
+	Equal(t, stats.Misses, uint(1))
 
 	guard := c.LockGuard()
 	guard.T.Set("1", 1)
@@ -62,3 +69,51 @@ func BenchmarkLFUThreadSafeCacheGetSetMultipleOperationLockParallel(b *testing.B
 		}
 	})
 }
+
+func TestLFUThreadSafeCacheAcquire(t *testing.T) {
+	c := New[string, *conn](8).CloseValues(nil).BuildThreadSafe()
+	_, found := c.Acquire("a")
+	Equal(t, found, false)
+
+	// values must never be closed while pinned and every value closed once it has left the cache.
+	var wg sync.WaitGroup
+	conns := make([][]*conn, 8)
+	for g := range conns {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1_000; i++ {
+				key := strconv.Itoa((i * (g + 1)) % 16)
+				value := &conn{name: key}
+				conns[g] = append(conns[g], value)
+				c.Set(key, value)
+				if h, found := c.Acquire(key); found {
+					runtime.Gosched() // while in use
+					if h.Value().closed != 0 {
+						t.Errorf("%s closed while pinned", key)
+					}
+					h.Release()
+				}
+				if i%100 == 0 {
+					c.Remove(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Clear()
+	stats := c.Stats()
+	Equal(t, stats.Pinned, 0)
+	Equal(t, stats.PinnedWeight, 0)
+	for _, values := range conns {
+		for _, value := range values {
+			Equal(t, value.closed, 1)
+		}
+	}
+}
+
+func TestLFUThreadSafeCacheConformance(t *testing.T) {
+	cachetest.RunConformance(t, func(cfg cachetest.Config) cachetest.Cache[Stats] {
+		return New[int, int](cfg.Capacity).MaxAge(cfg.MaxAge).Clock(cfg.Clock).BuildThreadSafe()
+	}, cachetest.Options{Policy: cachetest.LFU})
+}
@@ -0,0 +1,43 @@
+package lru
+
+import (
+	listext "github.com/go-playground/pkg/v5/container/list"
+)
+
+// ghosts is a bounded list of keys recently evicted to remain within capacity, without their values, most recently
+// evicted at the front.
+type ghosts[K comparable] struct {
+	list  *listext.DoublyLinkedList[K]
+	nodes map[K]*listext.Node[K]
+	size  int
+}
+
+func newGhosts[K comparable](size int) *ghosts[K] {
+	return &ghosts[K]{
+		list:  listext.NewDoublyLinked[K](),
+		nodes: make(map[K]*listext.Node[K]),
+		size:  size,
+	}
+}
+
+// add records the key as evicted, forgetting the oldest if full.
+func (g *ghosts[K]) add(key K) {
+	if node, found := g.nodes[key]; found {
+		g.list.MoveToFront(node)
+		return
+	}
+	if g.list.Len() == g.size {
+		delete(g.nodes, g.list.PopBack().Value)
+	}
+	g.nodes[key] = g.list.PushFront(key)
+}
+
+// remove forgets the key returning if it was recorded.
+func (g *ghosts[K]) remove(key K) bool {
+	node, found := g.nodes[key]
+	if found {
+		delete(g.nodes, key)
+		g.list.Remove(node)
+	}
+	return found
+}
@@ -0,0 +1,10 @@
+package lru
+
+import (
+	"github.com/go-playground/cache/internal/handle"
+)
+
+// Handle is a reference to an entries value returned by Acquire which pins the entry, preventing it from being evicted
+// to remain within capacity and deferring the OnEvict function and CloseValues, should it leave the cache by other
+// means, until every handle to it has been released.
+type Handle[V any] = handle.Handle[V]
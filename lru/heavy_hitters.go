@@ -0,0 +1,115 @@
+package lru
+
+import (
+	"cmp"
+	"slices"
+)
+
+// KeyCount is a key along with its approximate number of gets as reported by TopK.
+type KeyCount[K comparable] struct {
+	// Key is the key.
+	Key K
+
+	// Count is the number of gets for the key, which may be overestimated by up to Error.
+	Count int
+
+	// Error is the maximum amount Count may be overestimated by, Count-Error being the guaranteed minimum.
+	Error int
+}
+
+// heavyHitters tracks the most frequently accessed keys within a fixed number of counters using the Space-Saving
+// algorithm. Counters are held in a min heap by count so the least frequent can be replaced by a new key, the new key
+// inheriting its count as the error.
+type heavyHitters[K comparable] struct {
+	heap    []KeyCount[K]
+	indexes map[K]int
+	size    int
+}
+
+func newHeavyHitters[K comparable](size int) *heavyHitters[K] {
+	return &heavyHitters[K]{
+		heap:    make([]KeyCount[K], 0, size),
+		indexes: make(map[K]int, size),
+		size:    size,
+	}
+}
+
+// add counts an access to the key.
+func (h *heavyHitters[K]) add(key K) {
+	i, found := h.indexes[key]
+	switch {
+	case found:
+		h.heap[i].Count++
+		h.down(i)
+	case len(h.heap) < h.size:
+		h.heap = append(h.heap, KeyCount[K]{Key: key, Count: 1})
+		h.indexes[key] = len(h.heap) - 1
+		h.up(len(h.heap) - 1)
+	default:
+		// replace the least frequent key, whose count may have included this key before it was itself replaced.
+		least := h.heap[0]
+		delete(h.indexes, least.Key)
+		h.heap[0] = KeyCount[K]{Key: key, Count: least.Count + 1, Error: least.Count}
+		h.indexes[key] = 0
+		h.down(0)
+	}
+}
+
+// topK returns up to n keys with the highest counts in descending order.
+func (h *heavyHitters[K]) topK(n int) []KeyCount[K] {
+	counts := make([]KeyCount[K], len(h.heap))
+	copy(counts, h.heap)
+	sortKeyCounts(counts)
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+func (h *heavyHitters[K]) less(i, j int) bool {
+	return h.heap[i].Count < h.heap[j].Count
+}
+
+func (h *heavyHitters[K]) swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.indexes[h.heap[i].Key] = i
+	h.indexes[h.heap[j].Key] = j
+}
+
+func (h *heavyHitters[K]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *heavyHitters[K]) down(i int) {
+	for {
+		smallest := i
+		if l := 2*i + 1; l < len(h.heap) && h.less(l, smallest) {
+			smallest = l
+		}
+		if r := 2*i + 2; r < len(h.heap) && h.less(r, smallest) {
+			smallest = r
+		}
+		if smallest == i {
+			return
+		}
+		h.swap(i, smallest)
+		i = smallest
+	}
+}
+
+// sortKeyCounts sorts by count descending, then by error ascending as the more certain count.
+func sortKeyCounts[K comparable](counts []KeyCount[K]) {
+	slices.SortStableFunc(counts, func(a, b KeyCount[K]) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Error, b.Error)
+	})
+}
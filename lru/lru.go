@@ -1,24 +1,32 @@
 package lru
 
 import (
+	"github.com/go-playground/cache/internal/cachelog"
+	"github.com/go-playground/cache/internal/closer"
+	"github.com/go-playground/cache/internal/handle"
 	listext "github.com/go-playground/pkg/v5/container/list"
 	syncext "github.com/go-playground/pkg/v5/sync"
 	timeext "github.com/go-playground/pkg/v5/time"
 	optionext "github.com/go-playground/pkg/v5/values/option"
+	"log/slog"
 	"time"
 )
 
 type builder[K comparable, V any] struct {
-	lru *Cache[K, V]
+	lru         *Cache[K, V]
+	logger      *slog.Logger
+	logSampling int
 }
 
 // New initializes a builder to create an LRU cache.
 func New[K comparable, V any](capacity int) *builder[K, V] {
 	return &builder[K, V]{
+		logSampling: 1,
 		lru: &Cache[K, V]{
 			list:  listext.NewDoublyLinked[entry[K, V]](),
 			nodes: make(map[K]*listext.Node[entry[K, V]]),
 			stats: Stats{Capacity: capacity},
+			now:   timeext.NewInstant,
 		},
 	}
 }
@@ -34,10 +42,102 @@ func (b *builder[K, V]) MaxAge(maxAge time.Duration) *builder[K, V] {
 	return b
 }
 
+// Weigher sets a function returning the weight of each entry, such as its size in bytes, with the capacity then
+// bounding the total weight rather than the number of entries. Entries recorded using SetMissing weigh 1.
+//
+// The weight must be a positive value. An entry weighing more than the capacity is rejected rather than evicting every
+// other entry, any current entry for its key being removed, and counted as a Rejection in Stats.
+//
+// Default weighs every entry as 1.
+func (b *builder[K, V]) Weigher(fn func(key K, value V) int) *builder[K, V] {
+	b.lru.weigher = fn
+	return b
+}
+
+// Ghosts sets the number of keys recently evicted to remain within capacity to remember, without their values, so a
+// miss on one is counted as an EvictionMisses in Stats. A high count relative to Misses indicates a larger capacity
+// would help.
+//
+// Default is disabled.
+func (b *builder[K, V]) Ghosts(size int) *builder[K, V] {
+	if size <= 0 {
+		panic("Ghosts must be a positive value")
+	}
+	b.lru.ghosts = newGhosts[K](size)
+	return b
+}
+
+// HeavyHitters sets the number of counters used to track the most frequently gotten keys, reported by TopK. Every get
+// is counted whether a hit or miss using the Space-Saving algorithm, which guarantees to report any key gotten more
+// than 1/size of the time, with counts that may be overestimated by their reported Error.
+//
+// Default is disabled.
+func (b *builder[K, V]) HeavyHitters(size int) *builder[K, V] {
+	if size <= 0 {
+		panic("HeavyHitters must be a positive value")
+	}
+	b.lru.hitters = newHeavyHitters[K](size)
+	return b
+}
+
+// Logger sets a logger to emit debug level records for entries evicted to remain within capacity, expired or removed,
+// along with resizes and clears. Use logger.With to identify the cache. Nothing is logged when unset.
+//
+// Default is unset.
+func (b *builder[K, V]) Logger(logger *slog.Logger) *builder[K, V] {
+	b.logger = logger
+	return b
+}
+
+// LogSampling sets the Logger to log one in every n records for entries leaving the cache, so hot paths do not flood
+// the logger. Resizes and clears are always logged.
+//
+// Default is 1, logging every record.
+func (b *builder[K, V]) LogSampling(n int) *builder[K, V] {
+	if n <= 0 {
+		panic("LogSampling must be a positive value")
+	}
+	b.logSampling = n
+	return b
+}
+
+// Clock sets the function returning the current monotonic instant used to age entries, such as a fake clock in tests.
+//
+// Default is timeext.NewInstant.
+func (b *builder[K, V]) Clock(now func() timeext.Instant) *builder[K, V] {
+	b.lru.now = now
+	return b
+}
+
+// CloseValues sets values implementing io.Closer to be closed whenever their entry leaves the cache for any reason,
+// including Clear, Resize and expiry, or has its value replaced by a different value. Errors returned by Close are
+// passed to onError, which may be nil to ignore them.
+//
+// Close is called after any OnEvict function while any lock guarding the cache is held and so must not call back
+// into the same cache. Use Acquire rather than Get to prevent a value still in use from being closed.
+func (b *builder[K, V]) CloseValues(onError func(key K, err error)) *builder[K, V] {
+	b.lru.closeValues = true
+	b.lru.onCloseError = onError
+	return b
+}
+
+// OnEvict sets a function to be called whenever an entry leaves the cache, or has its value replaced, along with the
+// Reason. Entries recorded using SetMissing hold no value and are not reported.
+//
+// The function is called while any lock guarding the cache is held and so must not call back into the same cache.
+func (b *builder[K, V]) OnEvict(fn func(key K, value V, reason Reason)) *builder[K, V] {
+	b.lru.onEvict = fn
+	return b
+}
+
 // Build finalizes configuration and returns the LRU cache for use.
 func (b *builder[K, V]) Build() (lru *Cache[K, V]) {
 	lru = b.lru
 	b.lru = nil
+	if b.logger != nil {
+		lru.logger = cachelog.New(b.logger)
+		lru.logger.SetSampling(b.logSampling)
+	}
 	return
 }
 
@@ -48,6 +148,44 @@ func (b *builder[K, V]) BuildThreadSafe() ThreadSafeCache[K, V] {
 	}
 }
 
+// BuildConcurrent finalizes configuration and returns an LRU cache optimized for concurrent reads. See ConcurrentCache.
+func (b *builder[K, V]) BuildConcurrent() *ConcurrentCache[K, V] {
+	return newConcurrent(b.Build())
+}
+
+// Reason describes why an entry left the cache, or had its value replaced, when reported to the OnEvict function.
+type Reason uint8
+
+const (
+	// Capacity is when the entry was evicted to remain within capacity.
+	Capacity Reason = iota
+
+	// Expired is when the entry was discarded for exceeding its max age.
+	Expired
+
+	// Removed is when the entry was explicitly removed via Remove or Clear.
+	Removed
+
+	// Replaced is when the entries value was replaced by setting the same key.
+	Replaced
+)
+
+// String returns the lowercase name of the Reason.
+func (r Reason) String() string {
+	switch r {
+	case Capacity:
+		return "capacity"
+	case Expired:
+		return "expired"
+	case Removed:
+		return "removed"
+	case Replaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
 // Stats represents the cache statistics.
 type Stats struct {
 	// Capacity is the maximum cache capacity.
@@ -56,90 +194,446 @@ type Stats struct {
 	// Len is the current consumed cache capacity.
 	Len int
 
+	// Weight is the current total weight of all entries, equal to Len unless a Weigher is set.
+	Weight int
+
 	// Hits is the number of cache hits.
 	Hits uint
 
 	// Misses is the number of cache misses.
 	Misses uint
 
-	// Evictions is the number of cache evictions performed.
+	// Evictions is the number of cache evictions performed, the sum of CapacityEvictions and Expirations.
 	Evictions uint
 
+	// CapacityEvictions is the number of entries evicted to remain within capacity.
+	CapacityEvictions uint
+
+	// Expirations is the number of entries discarded for exceeding their max age.
+	Expirations uint
+
+	// Removals is the number of entries explicitly removed via Remove.
+	Removals uint
+
+	// Replacements is the number of sets which replaced an existing entry.
+	Replacements uint
+
 	// Gets is the number of cache gets performed regardless of a hit or miss.
 	Gets uint
 
 	// Sets is the number of cache sets performed.
 	Sets uint
+
+	// Rejections is the number of sets rejected for weighing more than the capacity.
+	Rejections uint
+
+	// NegativeHits is the number of cache gets which found a key recorded as known to be absent.
+	NegativeHits uint
+
+	// EvictionMisses is the number of misses for keys recently evicted to remain within capacity, only counted when
+	// Ghosts is set. These misses would have been hits with a larger capacity.
+	EvictionMisses uint
+
+	// Pinned is the current number of entries with handles returned by Acquire not yet released, including those
+	// which have since left the cache.
+	Pinned int
+
+	// PinnedWeight is the current total weight of pinned entries.
+	PinnedWeight int
+}
+
+// Entry is a cache entries value along with its metadata.
+type Entry[V any] struct {
+	// Value is the cached value.
+	Value V
+
+	// Inserted is the instant the value was set, as returned by the caches Clock. Entries are only timestamped when a
+	// max age applies and so it is zero if it has no max age.
+	Inserted timeext.Instant
+
+	// Age is the duration since the value was set or zero if it has no max age.
+	Age time.Duration
+
+	// TTL is the remaining duration before the entry expires or zero if it has no max age.
+	TTL time.Duration
+
+	// Position is the entries position in the recency order, zero being the most recently used.
+	Position int
 }
 
 type entry[K comparable, V any] struct {
 	key       K
 	value     V
 	timestamp timeext.Instant
+	maxAge    time.Duration
+	weight    int
+	missing   bool
+	// pins is the number of handles to the entry not yet released.
+	pins int
+	// reason and close are why the entry left the cache and if its value is to be closed upon finalization, deferred
+	// until released if pinned.
+	reason Reason
+	close  bool
 }
 
 // Cache is a configured least recently used cache ready for use.
 type Cache[K comparable, V any] struct {
-	list   *listext.DoublyLinkedList[entry[K, V]]
-	nodes  map[K]*listext.Node[entry[K, V]]
-	maxAge time.Duration
-	stats  Stats
+	list    *listext.DoublyLinkedList[entry[K, V]]
+	nodes   map[K]*listext.Node[entry[K, V]]
+	maxAge  time.Duration
+	stats   Stats
+	weight  int
+	weigher func(key K, value V) int
+	onEvict func(key K, value V, reason Reason)
+	// closeValues is if values implementing io.Closer are closed upon leaving the cache.
+	closeValues  bool
+	onCloseError func(key K, err error)
+	logger       *cachelog.Logger
+	ghosts       *ghosts[K]
+	hitters      *heavyHitters[K]
+	now          func() timeext.Instant
+	// pinned and pinnedWeight are the number and total weight of entries with handles not yet released.
+	pinned       int
+	pinnedWeight int
 }
 
 // Set sets an item into the cache. It will replace the current entry if there is one.
 func (cache *Cache[K, V]) Set(key K, value V) {
+	cache.set(key, value, false, 0)
+}
+
+// SetWithMaxAge sets an item into the cache with its own maxAge overriding the caches MaxAge. It will replace the
+// current entry if there is one.
+func (cache *Cache[K, V]) SetWithMaxAge(key K, value V, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetWithMaxAge maxAge is not permitted to be a negative value")
+	}
+	cache.set(key, value, false, maxAge)
+}
+
+// SetMissing records the key as known to be absent, for example not found in the backing store, for the provided
+// maxAge which is usually shorter than the caches MaxAge. A maxAge of zero uses the caches MaxAge.
+//
+// Get will return None for the key while Lookup reports it as missing, allowing a caller to distinguish between a
+// known absent key and one not cached at all. It will replace the current entry if there is one.
+func (cache *Cache[K, V]) SetMissing(key K, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetMissing maxAge is not permitted to be a negative value")
+	}
+	var value V
+	cache.set(key, value, true, maxAge)
+}
+
+func (cache *Cache[K, V]) set(key K, value V, missing bool, maxAge time.Duration) {
 	cache.stats.Sets++
 
+	weight := cache.weigh(key, value, missing)
+	if weight > cache.stats.Capacity {
+		cache.stats.Rejections++
+		cache.Remove(key)
+		return
+	}
+
+	// only timestamped when aged, as getting the time on every set is comparatively expensive.
+	var timestamp timeext.Instant
+	if maxAge > 0 || cache.maxAge > 0 {
+		timestamp = cache.now()
+	}
+
 	node, found := cache.nodes[key]
 	if found {
-		node.Value.value = value
-		if cache.maxAge > 0 {
-			node.Value.timestamp = timeext.NewInstant()
+		cache.stats.Replacements++
+		cache.replaced(&node.Value, value)
+		if node.Value.pins > 0 {
+			node = cache.detach(node)
 		}
+		cache.weight += weight - node.Value.weight
+		node.Value.value = value
+		node.Value.missing = missing
+		node.Value.maxAge = maxAge
+		node.Value.weight = weight
+		node.Value.timestamp = timestamp
 		cache.list.MoveToFront(node)
 	} else {
 		e := entry[K, V]{
-			key:   key,
-			value: value,
-		}
-		if cache.maxAge > 0 {
-			e.timestamp = timeext.NewInstant()
+			key:       key,
+			value:     value,
+			timestamp: timestamp,
+			maxAge:    maxAge,
+			weight:    weight,
+			missing:   missing,
 		}
 		cache.nodes[key] = cache.list.PushFront(e)
-		if cache.list.Len() > cache.stats.Capacity {
-			entry := cache.list.PopBack()
-			delete(cache.nodes, entry.Value.key)
-			cache.stats.Evictions++
+		cache.weight += weight
+		if cache.ghosts != nil {
+			cache.ghosts.remove(key)
 		}
 	}
+	for cache.weight > cache.stats.Capacity && cache.evict() {
+	}
+}
+
+// detach replaces the node of a pinned entry, whose value is being replaced, with a copy in the same position so the
+// value remains unchanged for the handles to it.
+func (cache *Cache[K, V]) detach(node *listext.Node[entry[K, V]]) *listext.Node[entry[K, V]] {
+	e := node.Value
+	e.pins = 0
+	replacement := cache.list.PushBefore(node, e)
+	cache.list.Remove(node)
+	cache.nodes[e.key] = replacement
+	return replacement
+}
+
+// evict removes the least recently used entry not pinned from the cache, returning false if every entry is pinned.
+func (cache *Cache[K, V]) evict() bool {
+	entry := cache.list.Back()
+	for entry != nil && entry.Value.pins > 0 {
+		entry = entry.Prev()
+	}
+	if entry == nil {
+		return false
+	}
+	cache.list.Remove(entry)
+	delete(cache.nodes, entry.Value.key)
+	cache.weight -= entry.Value.weight
+	cache.stats.Evictions++
+	cache.stats.CapacityEvictions++
+	if cache.ghosts != nil {
+		cache.ghosts.add(entry.Value.key)
+	}
+	cache.evicted(&entry.Value, Capacity)
+	cache.logEvicted(entry.Value.key, Capacity)
+	return true
+}
+
+// weigh returns the weight of an entry.
+func (cache *Cache[K, V]) weigh(key K, value V, missing bool) int {
+	if cache.weigher == nil || missing {
+		return 1
+	}
+	weight := cache.weigher(key, value)
+	if weight <= 0 {
+		panic("Weigher weight must be a positive value")
+	}
+	return weight
+}
+
+// evicted finalizes the entry leaving the cache, or having its value replaced, deferring until released if pinned.
+// Unless replaced, its value is closed if CloseValues is set.
+func (cache *Cache[K, V]) evicted(e *entry[K, V], reason Reason) {
+	if e.missing {
+		return
+	}
+	e.reason, e.close = reason, reason != Replaced
+	if e.pins == 0 {
+		cache.finalize(e)
+	}
+}
+
+// replaced finalizes the entry having its value replaced, closing the value if CloseValues is set and the replacement
+// is a different value.
+func (cache *Cache[K, V]) replaced(e *entry[K, V], value V) {
+	cache.evicted(e, Replaced)
+	if cache.closeValues && !e.missing && !closer.Same(e.value, value) {
+		if e.pins > 0 {
+			e.close = true
+		} else {
+			closer.Close(e.key, e.value, cache.onCloseError)
+		}
+	}
+}
+
+// finalize reports the entry to the OnEvict function if set and closes its value if it is to be closed.
+func (cache *Cache[K, V]) finalize(e *entry[K, V]) {
+	if cache.onEvict != nil {
+		cache.onEvict(e.key, e.value, e.reason)
+	}
+	if cache.closeValues && e.close {
+		closer.Close(e.key, e.value, cache.onCloseError)
+	}
+}
+
+// logEvicted logs the entry leaving the cache if a Logger is set and the record is sampled.
+func (cache *Cache[K, V]) logEvicted(key K, reason Reason) {
+	if cache.logger != nil && cache.logger.Sample() {
+		cache.logger.Evicted(key, reason.String())
+	}
+}
+
+// entryMaxAge returns the entries own maxAge, if set, otherwise the caches MaxAge.
+func (cache *Cache[K, V]) entryMaxAge(e *entry[K, V]) time.Duration {
+	if e.maxAge > 0 {
+		return e.maxAge
+	}
+	return cache.maxAge
+}
+
+// expired returns if the entry has outlived its max age.
+func (cache *Cache[K, V]) expired(e *entry[K, V]) bool {
+	maxAge := cache.entryMaxAge(e)
+	return maxAge > 0 && cache.now().Since(e.timestamp) > maxAge
 }
 
 // Get attempts to find an existing cache entry by key.
 // It returns an Option you must check before using the underlying value.
 func (cache *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
+	result, _ = cache.Lookup(key)
+	return
+}
+
+// Lookup attempts to find an existing cache entry by key the same as Get but additionally reports if the key was
+// recorded as known to be absent using SetMissing.
+func (cache *Cache[K, V]) Lookup(key K) (result optionext.Option[V], missing bool) {
 	cache.stats.Gets++
+	if cache.hitters != nil {
+		cache.hitters.add(key)
+	}
 
 	node, found := cache.nodes[key]
 	if found {
-		if cache.maxAge > 0 && node.Value.timestamp.Elapsed() > cache.maxAge {
-			delete(cache.nodes, key)
-			cache.list.Remove(node)
+		if cache.expired(&node.Value) {
+			cache.remove(node)
 			cache.stats.Evictions++
+			cache.stats.Expirations++
+			cache.evicted(&node.Value, Expired)
+			cache.logEvicted(key, Expired)
 		} else {
 			cache.list.MoveToFront(node)
-			result = optionext.Some(node.Value.value)
-			cache.stats.Hits++
+			if node.Value.missing {
+				missing = true
+				cache.stats.NegativeHits++
+			} else {
+				result = optionext.Some(node.Value.value)
+				cache.stats.Hits++
+			}
 		}
 	} else {
 		cache.stats.Misses++
+		if cache.ghosts != nil && cache.ghosts.remove(key) {
+			cache.stats.EvictionMisses++
+		}
 	}
 	return
 }
 
+// Acquire attempts to find an existing cache entry by key the same as Get, returning a Handle to its value which pins
+// the entry until released. A pinned entry is not evicted to remain within capacity, which may temporarily be exceeded
+// if every entry is pinned, and should it leave the cache by other means its OnEvict function and CloseValues are
+// deferred until every handle to it is released.
+//
+// The handle must be released using the same locking as the cache.
+func (cache *Cache[K, V]) Acquire(key K) (Handle[V], bool) {
+	node, found := cache.acquire(key)
+	if !found {
+		return Handle[V]{}, false
+	}
+	return handle.New(node.Value.value, func() { cache.release(node) }), true
+}
+
+// acquire looks up the key the same as Get, pinning the entry if found.
+func (cache *Cache[K, V]) acquire(key K) (node *listext.Node[entry[K, V]], found bool) {
+	if result, _ := cache.Lookup(key); result.IsNone() {
+		return nil, false
+	}
+	node = cache.nodes[key]
+	if node.Value.pins == 0 {
+		cache.pinned++
+		cache.pinnedWeight += node.Value.weight
+	}
+	node.Value.pins++
+	return node, true
+}
+
+// release releases a handle to the entry. Once no handles remain it is finalized if it has left the cache, otherwise
+// entries are evicted should the cache have exceeded capacity while it was pinned.
+func (cache *Cache[K, V]) release(node *listext.Node[entry[K, V]]) {
+	e := &node.Value
+	if e.pins--; e.pins > 0 {
+		return
+	}
+	cache.pinned--
+	cache.pinnedWeight -= e.weight
+	if cache.nodes[e.key] != node {
+		// has left the cache.
+		cache.finalize(e)
+		return
+	}
+	for cache.weight > cache.stats.Capacity && cache.evict() {
+	}
+}
+
+// GetEntry attempts to find an existing cache entry by key returning its value along with metadata.
+// It does not count as an access, affect the recency order or record stats, making it suitable for debugging and
+// adaptive logic. Entries known to be absent or past their max age are reported as not found.
+//
+// Determining the Position walks the recency list and so is O(n), intended for debugging rather than hot paths.
+func (cache *Cache[K, V]) GetEntry(key K) (result optionext.Option[Entry[V]]) {
+	node, found := cache.nodes[key]
+	if !found || node.Value.missing || cache.expired(&node.Value) {
+		return
+	}
+	var position int
+	for n := cache.list.Front(); n != node; n = n.Next() {
+		position++
+	}
+	return optionext.Some(cache.entry(&node.Value, position))
+}
+
+// entry returns the entries value along with its metadata.
+func (cache *Cache[K, V]) entry(e *entry[K, V], position int) Entry[V] {
+	result := Entry[V]{
+		Value:    e.value,
+		Position: position,
+	}
+	if maxAge := cache.entryMaxAge(e); maxAge > 0 {
+		result.Inserted = e.timestamp
+		result.Age = cache.now().Since(e.timestamp)
+		result.TTL = maxAge - result.Age
+	}
+	return result
+}
+
+// TopK returns up to n of the most frequently gotten keys, in descending order of count, as tracked since the cache
+// was built. It returns nil unless HeavyHitters is set.
+func (cache *Cache[K, V]) TopK(n int) []KeyCount[K] {
+	if n <= 0 {
+		panic("TopK n must be a positive value")
+	}
+	if cache.hitters == nil {
+		return nil
+	}
+	return cache.hitters.topK(n)
+}
+
+// Range calls fn for each entry in recency order, most recently used first, until fn returns false. It does not count
+// as an access, affect the recency order or record stats. Entries known to be absent or past their max age are
+// skipped.
+//
+// fn must not modify the cache.
+func (cache *Cache[K, V]) Range(fn func(key K, entry Entry[V]) bool) {
+	var position int
+	for node := cache.list.Front(); node != nil; node = node.Next() {
+		if !node.Value.missing && !cache.expired(&node.Value) {
+			if !fn(node.Value.key, cache.entry(&node.Value, position)) {
+				return
+			}
+		}
+		position++
+	}
+}
+
+// MaxAge returns the caches MaxAge, zero being no max age.
+func (cache *Cache[K, V]) MaxAge() time.Duration {
+	return cache.maxAge
+}
+
 // Remove removes the item matching the provided key from the cache, if not present is a noop.
 func (cache *Cache[K, V]) Remove(key K) {
 	if node, found := cache.nodes[key]; found {
 		cache.remove(node)
+		cache.stats.Removals++
+		cache.evicted(&node.Value, Removed)
+		cache.logEvicted(key, Removed)
 	}
 }
 
@@ -147,26 +641,63 @@ func (cache *Cache[K, V]) remove(node *listext.Node[entry[K, V]]) {
 	if node, found := cache.nodes[node.Value.key]; found {
 		delete(cache.nodes, node.Value.key)
 		cache.list.Remove(node)
+		cache.weight -= node.Value.weight
 	}
 }
 
+// Capacity returns the maximum capacity of the cache.
+func (cache *Cache[K, V]) Capacity() int {
+	return cache.stats.Capacity
+}
+
+// Resize changes the maximum capacity of the cache. If the new capacity is lower than the current consumed capacity
+// the least recently used entries are evicted immediately.
+func (cache *Cache[K, V]) Resize(capacity int) {
+	if capacity < 0 {
+		panic("Resize is not permitted to be a negative value")
+	}
+	from := cache.stats.Capacity
+	evicted := cache.resize(capacity)
+	if cache.logger != nil {
+		cache.logger.Resized(from, capacity, evicted)
+	}
+}
+
+// resize changes the maximum capacity of the cache returning the number of entries evicted.
+func (cache *Cache[K, V]) resize(capacity int) (evicted int) {
+	cache.stats.Capacity = capacity
+	for ; cache.weight > capacity && cache.evict(); evicted++ {
+	}
+	return
+}
+
 // Clear empties the cache.
 func (cache *Cache[K, V]) Clear() {
+	removed := cache.clear()
+	if cache.logger != nil {
+		cache.logger.Cleared(removed)
+	}
+}
+
+// clear empties the cache returning the number of entries removed.
+func (cache *Cache[K, V]) clear() (removed int) {
+	removed = len(cache.nodes)
 	for _, node := range cache.nodes {
 		cache.remove(node)
+		cache.evicted(&node.Value, Removed)
 	}
 	// resets/empties stats
 	_ = cache.Stats()
+	return
 }
 
 // Stats returns the delta of Stats since last call to the Stats function.
 func (cache *Cache[K, V]) Stats() (stats Stats) {
 	stats = cache.stats
 	stats.Len = cache.list.Len()
-	cache.stats.Hits = 0
-	cache.stats.Misses = 0
-	cache.stats.Evictions = 0
-	cache.stats.Gets = 0
-	cache.stats.Sets = 0
+	stats.Weight = cache.weight
+	stats.Pinned = cache.pinned
+	stats.PinnedWeight = cache.pinnedWeight
+	cache.stats = Stats{Capacity: cache.stats.Capacity}
 	return
 }
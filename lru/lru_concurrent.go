@@ -0,0 +1,545 @@
+package lru
+
+import (
+	"github.com/go-playground/cache/internal/cachelog"
+	"github.com/go-playground/cache/internal/closer"
+	"github.com/go-playground/cache/internal/handle"
+	listext "github.com/go-playground/pkg/v5/container/list"
+	syncext "github.com/go-playground/pkg/v5/sync"
+	timeext "github.com/go-playground/pkg/v5/time"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// readBufferSize is the number of accesses each read buffer holds before being drained, must be a power of two.
+	readBufferSize = 16
+)
+
+type concurrentEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	timestamp timeext.Instant
+	maxAge    time.Duration
+	weight    int
+	missing   bool
+	// node is only accessed while holding the policy lock and is nil once the entry has left the cache.
+	node *listext.Node[*concurrentEntry[K, V]]
+	// pins, reason and close are only accessed while holding the policy lock. pins is the number of handles to the
+	// entry not yet released, reason and close are why the entry left the cache and if its value is to be closed upon
+	// finalization, deferred until released if pinned.
+	pins   int
+	reason Reason
+	close  bool
+}
+
+// readBuffer is a lossy ring buffer recording accesses to be replayed against the recency order in batches.
+type readBuffer[K comparable, V any] struct {
+	writes atomic.Uint64
+	slots  [readBufferSize]atomic.Pointer[concurrentEntry[K, V]]
+	_      [64]byte // prevent false sharing between stripes
+}
+
+type concurrentPolicy[K comparable, V any] struct {
+	list   *listext.DoublyLinkedList[*concurrentEntry[K, V]]
+	weight int
+	// stats holds the counters only modified while holding the policy lock.
+	stats  Stats
+	ghosts *ghosts[K]
+	// pinned and pinnedWeight are the number and total weight of entries with handles not yet released.
+	pinned       int
+	pinnedWeight int
+}
+
+// ConcurrentCache is an LRU cache designed for read heavy concurrent use, API compatible with ThreadSafeCache
+// apart from LockGuard.
+//
+// Gets perform a lock free lookup and record the access into striped lossy read buffers which are replayed against
+// the recency order in batches under a try-lock, rather than taking an exclusive lock on every Get. Recency is
+// therefore approximate, a small number of accesses may be dropped under heavy contention.
+type ConcurrentCache[K comparable, V any] struct {
+	entries sync.Map
+	policy  syncext.Mutex2[*concurrentPolicy[K, V]]
+	buffers []readBuffer[K, V]
+	maxAge  time.Duration
+	gets    atomic.Uint64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	negHits atomic.Uint64
+	weigher func(key K, value V) int
+	onEvict func(key K, value V, reason Reason)
+	// closeValues is if values implementing io.Closer are closed upon leaving the cache.
+	closeValues  bool
+	onCloseError func(key K, err error)
+	// ghosts is if evicted keys are tracked, avoiding the policy lock on misses when not.
+	ghosts bool
+	// hitters is nil unless HeavyHitters is set, guarded by its own lock to keep it off the policy lock.
+	hitters *syncext.Mutex2[*heavyHitters[K]]
+	logger  *cachelog.Logger
+	now     func() timeext.Instant
+}
+
+func newConcurrent[K comparable, V any](lru *Cache[K, V]) *ConcurrentCache[K, V] {
+	// enough stripes to keep contention low, rounded to a power of two for cheap selection.
+	stripes := 1 << bits.Len(uint(4*runtime.GOMAXPROCS(0)-1))
+	var hitters *syncext.Mutex2[*heavyHitters[K]]
+	if lru.hitters != nil {
+		m := syncext.NewMutex2(lru.hitters)
+		hitters = &m
+	}
+	return &ConcurrentCache[K, V]{
+		policy: syncext.NewMutex2(&concurrentPolicy[K, V]{
+			list:   listext.NewDoublyLinked[*concurrentEntry[K, V]](),
+			stats:  Stats{Capacity: lru.stats.Capacity},
+			ghosts: lru.ghosts,
+		}),
+		buffers:      make([]readBuffer[K, V], stripes),
+		maxAge:       lru.maxAge,
+		ghosts:       lru.ghosts != nil,
+		hitters:      hitters,
+		weigher:      lru.weigher,
+		onEvict:      lru.onEvict,
+		closeValues:  lru.closeValues,
+		onCloseError: lru.onCloseError,
+		logger:       lru.logger,
+		now:          lru.now,
+	}
+}
+
+// Set sets an item into the cache. It will replace the current entry if there is one.
+func (c *ConcurrentCache[K, V]) Set(key K, value V) {
+	c.set(&concurrentEntry[K, V]{
+		key:   key,
+		value: value,
+	})
+}
+
+// SetWithMaxAge sets an item into the cache with its own maxAge overriding the caches MaxAge. It will replace the
+// current entry if there is one.
+func (c *ConcurrentCache[K, V]) SetWithMaxAge(key K, value V, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetWithMaxAge maxAge is not permitted to be a negative value")
+	}
+	c.set(&concurrentEntry[K, V]{
+		key:    key,
+		value:  value,
+		maxAge: maxAge,
+	})
+}
+
+// SetMissing records the key as known to be absent for the provided maxAge. See Cache.SetMissing.
+func (c *ConcurrentCache[K, V]) SetMissing(key K, maxAge time.Duration) {
+	if maxAge < 0 {
+		panic("SetMissing maxAge is not permitted to be a negative value")
+	}
+	c.set(&concurrentEntry[K, V]{
+		key:     key,
+		maxAge:  maxAge,
+		missing: true,
+	})
+}
+
+func (c *ConcurrentCache[K, V]) set(e *concurrentEntry[K, V]) {
+	// only timestamped when aged, as getting the time on every set is comparatively expensive.
+	if e.maxAge > 0 || c.maxAge > 0 {
+		e.timestamp = c.now()
+	}
+	e.weight = 1
+	if c.weigher != nil && !e.missing {
+		if e.weight = c.weigher(e.key, e.value); e.weight <= 0 {
+			panic("Weigher weight must be a positive value")
+		}
+	}
+
+	guard := c.policy.Lock()
+	guard.T.stats.Sets++
+
+	if e.weight > guard.T.stats.Capacity {
+		guard.T.stats.Rejections++
+		c.remove(guard.T, e.key)
+		guard.Unlock()
+		return
+	}
+
+	if v, found := c.entries.Swap(e.key, e); found {
+		guard.T.stats.Replacements++
+		// entries are never mutated once visible to readers so replace in place within the recency order.
+		old := v.(*concurrentEntry[K, V])
+		e.node = old.node
+		e.node.Value = e
+		old.node = nil
+		guard.T.list.MoveToFront(e.node)
+		guard.T.weight += e.weight - old.weight
+		c.replaced(old, e.value)
+	} else {
+		e.node = guard.T.list.PushFront(e)
+		guard.T.weight += e.weight
+		if c.ghosts {
+			guard.T.ghosts.remove(e.key)
+		}
+	}
+	for guard.T.weight > guard.T.stats.Capacity && c.evict(guard.T) {
+	}
+	guard.Unlock()
+}
+
+// Get attempts to find an existing cache entry by key.
+// It returns an Option you must check before using the underlying value.
+func (c *ConcurrentCache[K, V]) Get(key K) (result optionext.Option[V]) {
+	result, _ = c.Lookup(key)
+	return
+}
+
+// Lookup attempts to find an existing cache entry by key the same as Get but additionally reports if the key was
+// recorded as known to be absent using SetMissing.
+func (c *ConcurrentCache[K, V]) Lookup(key K) (result optionext.Option[V], missing bool) {
+	if e := c.lookup(key); e != nil {
+		if e.missing {
+			return result, true
+		}
+		return optionext.Some(e.value), false
+	}
+	return
+}
+
+// Acquire attempts to find an existing cache entry by key the same as Get, returning a Handle to its value which pins
+// the entry until released. See Cache.Acquire.
+//
+// Unlike Get, acquiring and releasing a handle takes the policy lock.
+func (c *ConcurrentCache[K, V]) Acquire(key K) (Handle[V], bool) {
+	e := c.lookup(key)
+	if e == nil || e.missing {
+		return Handle[V]{}, false
+	}
+	guard := c.policy.Lock()
+	// may have been replaced or removed since being found.
+	if e.node == nil {
+		guard.Unlock()
+		return Handle[V]{}, false
+	}
+	if e.pins == 0 {
+		guard.T.pinned++
+		guard.T.pinnedWeight += e.weight
+	}
+	e.pins++
+	guard.Unlock()
+	return handle.New(e.value, func() { c.release(e) }), true
+}
+
+// release releases a handle to the entry. Once no handles remain it is finalized if it has left the cache, otherwise
+// entries are evicted should the cache have exceeded capacity while it was pinned.
+func (c *ConcurrentCache[K, V]) release(e *concurrentEntry[K, V]) {
+	guard := c.policy.Lock()
+	if e.pins--; e.pins == 0 {
+		guard.T.pinned--
+		guard.T.pinnedWeight -= e.weight
+		if e.node == nil {
+			c.finalize(e)
+		} else {
+			for guard.T.weight > guard.T.stats.Capacity && c.evict(guard.T) {
+			}
+		}
+	}
+	guard.Unlock()
+}
+
+// lookup finds the entry by key, counting the get as Lookup does, returning nil if not found or expired.
+func (c *ConcurrentCache[K, V]) lookup(key K) *concurrentEntry[K, V] {
+	c.gets.Add(1)
+	if c.hitters != nil {
+		guard := c.hitters.Lock()
+		guard.T.add(key)
+		guard.Unlock()
+	}
+
+	v, found := c.entries.Load(key)
+	if !found {
+		c.misses.Add(1)
+		if c.ghosts {
+			guard := c.policy.Lock()
+			if guard.T.ghosts.remove(key) {
+				guard.T.stats.EvictionMisses++
+			}
+			guard.Unlock()
+		}
+		return nil
+	}
+	e := v.(*concurrentEntry[K, V])
+	if c.expired(e) {
+		guard := c.policy.Lock()
+		// may have already been replaced or removed while waiting on the lock.
+		if e.node != nil {
+			c.entries.CompareAndDelete(key, e)
+			c.unlink(guard.T, e)
+			guard.T.stats.Evictions++
+			guard.T.stats.Expirations++
+			c.evicted(e, Expired)
+			c.logEvicted(key, Expired)
+		}
+		guard.Unlock()
+		return nil
+	}
+	c.record(e)
+	if e.missing {
+		c.negHits.Add(1)
+	} else {
+		c.hits.Add(1)
+	}
+	return e
+}
+
+// entryMaxAge returns the entries own maxAge, if set, otherwise the caches MaxAge.
+func (c *ConcurrentCache[K, V]) entryMaxAge(e *concurrentEntry[K, V]) time.Duration {
+	if e.maxAge > 0 {
+		return e.maxAge
+	}
+	return c.maxAge
+}
+
+// expired returns if the entry has outlived its max age.
+func (c *ConcurrentCache[K, V]) expired(e *concurrentEntry[K, V]) bool {
+	maxAge := c.entryMaxAge(e)
+	return maxAge > 0 && c.now().Since(e.timestamp) > maxAge
+}
+
+// GetEntry attempts to find an existing cache entry by key returning its value along with metadata without counting it
+// as an access. See Cache.GetEntry.
+//
+// Determining the Position takes the policy lock while walking the recency list, which does not yet reflect Gets
+// still held in the read buffers.
+func (c *ConcurrentCache[K, V]) GetEntry(key K) (result optionext.Option[Entry[V]]) {
+	v, found := c.entries.Load(key)
+	if !found {
+		return
+	}
+	e := v.(*concurrentEntry[K, V])
+	if e.missing || c.expired(e) {
+		return
+	}
+	entry := Entry[V]{
+		Value: e.value,
+	}
+	if maxAge := c.entryMaxAge(e); maxAge > 0 {
+		entry.Inserted = e.timestamp
+		entry.Age = c.now().Since(e.timestamp)
+		entry.TTL = maxAge - entry.Age
+	}
+
+	guard := c.policy.Lock()
+	// may have been replaced or removed in the meantime.
+	if e.node != nil {
+		for n := guard.T.list.Front(); n != e.node; n = n.Next() {
+			entry.Position++
+		}
+		result = optionext.Some(entry)
+	}
+	guard.Unlock()
+	return
+}
+
+// TopK returns up to n of the most frequently gotten keys. See Cache.TopK.
+//
+// Tracking heavy hitters takes a lock on every Get, separate from the policy lock, reducing read scalability.
+func (c *ConcurrentCache[K, V]) TopK(n int) (counts []KeyCount[K]) {
+	if n <= 0 {
+		panic("TopK n must be a positive value")
+	}
+	if c.hitters == nil {
+		return nil
+	}
+	guard := c.hitters.Lock()
+	counts = guard.T.topK(n)
+	guard.Unlock()
+	return
+}
+
+// record adds the access to a read buffer, draining the buffers if full and the policy lock is uncontended.
+func (c *ConcurrentCache[K, V]) record(e *concurrentEntry[K, V]) {
+	buffer := &c.buffers[rand.Uint32()&uint32(len(c.buffers)-1)]
+	i := buffer.writes.Add(1)
+	buffer.slots[i&(readBufferSize-1)].Store(e)
+
+	if i&(readBufferSize-1) == 0 {
+		if result := c.policy.TryLock(); result.IsOk() {
+			guard := result.Unwrap()
+			c.drain(guard.T)
+			guard.Unlock()
+		}
+	}
+}
+
+// drain replays all buffered accesses against the recency order. The policy lock must be held.
+func (c *ConcurrentCache[K, V]) drain(policy *concurrentPolicy[K, V]) {
+	for i := range c.buffers {
+		for j := range c.buffers[i].slots {
+			if e := c.buffers[i].slots[j].Swap(nil); e != nil && e.node != nil {
+				policy.list.MoveToFront(e.node)
+			}
+		}
+	}
+}
+
+// evict removes the least recently used entry not pinned, returning false if every entry is pinned. The policy lock
+// must be held.
+func (c *ConcurrentCache[K, V]) evict(policy *concurrentPolicy[K, V]) bool {
+	node := policy.list.Back()
+	for node != nil && node.Value.pins > 0 {
+		node = node.Prev()
+	}
+	if node == nil {
+		return false
+	}
+	policy.list.Remove(node)
+	c.entries.CompareAndDelete(node.Value.key, node.Value)
+	node.Value.node = nil
+	policy.weight -= node.Value.weight
+	policy.stats.Evictions++
+	policy.stats.CapacityEvictions++
+	if c.ghosts {
+		policy.ghosts.add(node.Value.key)
+	}
+	c.evicted(node.Value, Capacity)
+	c.logEvicted(node.Value.key, Capacity)
+	return true
+}
+
+// evicted finalizes the entry leaving the cache, or having its value replaced, deferring until released if pinned.
+// Unless replaced, its value is closed if CloseValues is set. The policy lock must be held.
+func (c *ConcurrentCache[K, V]) evicted(e *concurrentEntry[K, V], reason Reason) {
+	if e.missing {
+		return
+	}
+	e.reason, e.close = reason, reason != Replaced
+	if e.pins == 0 {
+		c.finalize(e)
+	}
+}
+
+// replaced finalizes the entry having its value replaced, closing the value if CloseValues is set and the replacement
+// is a different value. The policy lock must be held.
+func (c *ConcurrentCache[K, V]) replaced(e *concurrentEntry[K, V], value V) {
+	c.evicted(e, Replaced)
+	if c.closeValues && !e.missing && !closer.Same(e.value, value) {
+		if e.pins > 0 {
+			e.close = true
+		} else {
+			closer.Close(e.key, e.value, c.onCloseError)
+		}
+	}
+}
+
+// finalize reports the entry to the OnEvict function if set and closes its value if it is to be closed. The policy
+// lock must be held.
+func (c *ConcurrentCache[K, V]) finalize(e *concurrentEntry[K, V]) {
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+	if c.closeValues && e.close {
+		closer.Close(e.key, e.value, c.onCloseError)
+	}
+}
+
+// logEvicted logs the entry leaving the cache if a Logger is set and the record is sampled.
+func (c *ConcurrentCache[K, V]) logEvicted(key K, reason Reason) {
+	if c.logger != nil && c.logger.Sample() {
+		c.logger.Evicted(key, reason.String())
+	}
+}
+
+// unlink removes the entry from the recency order. The policy lock must be held.
+func (c *ConcurrentCache[K, V]) unlink(policy *concurrentPolicy[K, V], e *concurrentEntry[K, V]) {
+	policy.list.Remove(e.node)
+	policy.weight -= e.weight
+	e.node = nil
+}
+
+// Remove removes the item matching the provided key from the cache, if not present is a noop.
+func (c *ConcurrentCache[K, V]) Remove(key K) {
+	guard := c.policy.Lock()
+	c.remove(guard.T, key)
+	guard.Unlock()
+}
+
+func (c *ConcurrentCache[K, V]) remove(policy *concurrentPolicy[K, V], key K) {
+	if v, found := c.entries.LoadAndDelete(key); found {
+		e := v.(*concurrentEntry[K, V])
+		c.unlink(policy, e)
+		policy.stats.Removals++
+		c.evicted(e, Removed)
+		c.logEvicted(key, Removed)
+	}
+}
+
+// Capacity returns the maximum capacity of the cache.
+func (c *ConcurrentCache[K, V]) Capacity() (capacity int) {
+	guard := c.policy.Lock()
+	capacity = guard.T.stats.Capacity
+	guard.Unlock()
+	return
+}
+
+// Resize changes the maximum capacity of the cache. If the new capacity is lower than the current consumed capacity
+// the least recently used entries are evicted immediately.
+func (c *ConcurrentCache[K, V]) Resize(capacity int) {
+	if capacity < 0 {
+		panic("Resize is not permitted to be a negative value")
+	}
+	guard := c.policy.Lock()
+	// bring recency order up to date before choosing what to evict.
+	c.drain(guard.T)
+	from := guard.T.stats.Capacity
+	guard.T.stats.Capacity = capacity
+	var evicted int
+	for ; guard.T.weight > capacity && c.evict(guard.T); evicted++ {
+	}
+	guard.Unlock()
+	if c.logger != nil {
+		c.logger.Resized(from, capacity, evicted)
+	}
+}
+
+// Clear empties the cache.
+func (c *ConcurrentCache[K, V]) Clear() {
+	guard := c.policy.Lock()
+	c.drain(guard.T)
+	removed := guard.T.list.Len()
+	for node := guard.T.list.PopBack(); node != nil; node = guard.T.list.PopBack() {
+		c.entries.CompareAndDelete(node.Value.key, node.Value)
+		node.Value.node = nil
+		c.evicted(node.Value, Removed)
+	}
+	guard.T.weight = 0
+	// resets/empties stats
+	_ = c.stats(guard.T)
+	guard.Unlock()
+	if c.logger != nil {
+		c.logger.Cleared(removed)
+	}
+}
+
+// Stats returns the delta of Stats since last call to the Stats function.
+func (c *ConcurrentCache[K, V]) Stats() (stats Stats) {
+	guard := c.policy.Lock()
+	stats = c.stats(guard.T)
+	guard.Unlock()
+	return
+}
+
+func (c *ConcurrentCache[K, V]) stats(policy *concurrentPolicy[K, V]) (stats Stats) {
+	stats = policy.stats
+	stats.Len = policy.list.Len()
+	stats.Weight = policy.weight
+	stats.Pinned = policy.pinned
+	stats.PinnedWeight = policy.pinnedWeight
+	stats.Hits = uint(c.hits.Swap(0))
+	stats.Misses = uint(c.misses.Swap(0))
+	stats.Gets = uint(c.gets.Swap(0))
+	stats.NegativeHits = uint(c.negHits.Swap(0))
+	policy.stats = Stats{Capacity: policy.stats.Capacity}
+	return
+}
@@ -0,0 +1,349 @@
+package lru
+
+import (
+	"bytes"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/cachetest"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUConcurrentCache(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.Set("1", 10) // replacing, not a mistake
+	c.Set("4", 4)
+	Equal(t, c.Get("1"), optionext.Some(10))
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("3"), optionext.Some(3))
+	Equal(t, c.Get("4"), optionext.Some(4))
+
+	c.Remove("3")
+	Equal(t, c.Get("3"), optionext.None[int]())
+
+	stats := c.Stats()
+	Equal(t, stats.Capacity, 3)
+	Equal(t, stats.Len, 2)
+	Equal(t, stats.Hits, uint(3))
+	Equal(t, stats.Misses, uint(2))
+	Equal(t, stats.Gets, uint(5))
+	Equal(t, stats.Sets, uint(5))
+	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(1))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.Replacements, uint(1))
+
+	c.Resize(1)
+	stats = c.Stats()
+	Equal(t, stats.Capacity, 1)
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Evictions, uint(1))
+
+	c.Clear()
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, c.Get("4"), optionext.None[int]())
+	stats = c.Stats()
+	Equal(t, stats.Len, 0)
+	Equal(t, stats.Removals, uint(0))
+	Equal(t, stats.Misses, uint(2))
+	Equal(t, stats.Capacity, 1)
+}
+
+func TestLRUConcurrentCacheRecency(t *testing.T) {
+	c := New[string, int](2).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("2", 2)
+
+	// reads are buffered and replayed in batches so read enough times to trigger a drain.
+	for i := 0; i < readBufferSize*len(c.buffers); i++ {
+		Equal(t, c.Get("1"), optionext.Some(1))
+	}
+	c.Resize(2) // drains any remaining buffered reads
+	c.Set("3", 3)
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("3"), optionext.Some(3))
+}
+
+func TestLRUConcurrentCacheMaxAge(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Nanosecond).BuildConcurrent()
+	c.Set("1", 1)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("1"), optionext.None[int]())
+
+	stats := c.Stats()
+	Equal(t, stats.Len, 0)
+	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.Expirations, uint(1))
+}
+
+func TestLRUConcurrentCacheSetMissing(t *testing.T) {
+	c := New[string, int](3).BuildConcurrent()
+	c.SetMissing("1", time.Nanosecond)
+	c.SetMissing("2", time.Hour)
+
+	result, missing := c.Lookup("2")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, true)
+
+	time.Sleep(time.Second) // for windows :(
+	result, missing = c.Lookup("1")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, false)
+
+	c.Set("2", 2)
+	result, missing = c.Lookup("2")
+	Equal(t, result, optionext.Some(2))
+	Equal(t, missing, false)
+
+	stats := c.Stats()
+	Equal(t, stats.NegativeHits, uint(1))
+	Equal(t, stats.Hits, uint(1))
+	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.Len, 1)
+}
+
+func TestLRUConcurrentCacheGetEntry(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("2", 2)
+
+	option := c.GetEntry("1")
+	Equal(t, option.IsSome(), true)
+	e := option.Unwrap()
+	Equal(t, e.Value, 1)
+	v, _ := c.entries.Load("1")
+	Equal(t, e.Inserted, v.(*concurrentEntry[string, int]).timestamp)
+	Equal(t, e.TTL > 59*time.Minute && e.TTL <= time.Hour, true)
+	Equal(t, e.Position, 1)
+	Equal(t, c.GetEntry("2").Unwrap().Position, 0)
+
+	// GetEntry is not an access
+	c.Set("3", 3)
+	c.Set("4", 4) // evicts 1
+	Equal(t, c.GetEntry("1"), optionext.None[Entry[int]]())
+	Equal(t, c.GetEntry("5"), optionext.None[Entry[int]]())
+	Equal(t, c.Stats().Gets, uint(0))
+}
+
+func TestLRUConcurrentCacheOnEvict(t *testing.T) {
+	var reasons []Reason
+	c := New[string, int](1).OnEvict(func(key string, value int, reason Reason) {
+		reasons = append(reasons, reason)
+	}).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Remove("2")
+	c.Set("3", 3)
+	c.Clear()
+	Equal(t, reasons, []Reason{Replaced, Capacity, Removed, Removed})
+}
+
+func TestLRUConcurrentCacheCloseValues(t *testing.T) {
+	var errs int
+	c := New[string, *conn](1).CloseValues(func(key string, err error) { errs++ }).BuildConcurrent()
+	a, b, d := &conn{name: "a"}, &conn{name: "b", bad: true}, &conn{name: "d"}
+	c.Set("1", a)
+	c.Set("1", a)
+	Equal(t, a.closed, 0)
+	c.Set("1", b)
+	Equal(t, a.closed, 1)
+	c.Set("2", d) // evicts b
+	Equal(t, b.closed, 1)
+	Equal(t, errs, 1)
+	c.Clear()
+	Equal(t, d.closed, 1)
+}
+
+func TestLRUConcurrentCacheAcquire(t *testing.T) {
+	c := New[string, *conn](1).CloseValues(nil).BuildConcurrent()
+	a, b := &conn{name: "a"}, &conn{name: "b"}
+	c.Set("a", a)
+	h, found := c.Acquire("a")
+	Equal(t, found, true)
+	Equal(t, h.Value(), a)
+	c.Set("b", b) // the only entry not pinned
+	Equal(t, b.closed, 1)
+	c.Remove("a")
+	Equal(t, a.closed, 0)
+	stats := c.Stats()
+	Equal(t, stats.Pinned, 1)
+	Equal(t, stats.PinnedWeight, 1)
+	h.Release()
+	Equal(t, a.closed, 1)
+	_, found = c.Acquire("a")
+	Equal(t, found, false)
+	c.SetMissing("m", 0)
+	_, found = c.Acquire("m")
+	Equal(t, found, false)
+}
+
+func TestLRUConcurrentCacheAcquireConcurrency(t *testing.T) {
+	c := New[string, *conn](8).CloseValues(nil).BuildConcurrent()
+
+	// values must never be closed while pinned and every value closed once it has left the cache.
+	var wg sync.WaitGroup
+	conns := make([][]*conn, 8)
+	for g := range conns {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1_000; i++ {
+				key := strconv.Itoa((i * (g + 1)) % 16)
+				value := &conn{name: key}
+				conns[g] = append(conns[g], value)
+				c.Set(key, value)
+				if h, found := c.Acquire(key); found {
+					runtime.Gosched() // while in use
+					if h.Value().closed != 0 {
+						t.Errorf("%s closed while pinned", key)
+					}
+					h.Release()
+				}
+				if i%100 == 0 {
+					c.Remove(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Clear()
+	stats := c.Stats()
+	Equal(t, stats.Pinned, 0)
+	Equal(t, stats.PinnedWeight, 0)
+	for _, values := range conns {
+		for _, value := range values {
+			Equal(t, value.closed, 1)
+		}
+	}
+}
+
+func TestLRUConcurrentCacheWeigher(t *testing.T) {
+	c := New[string, string](7).Weigher(func(key string, value string) int {
+		return len(value)
+	}).BuildConcurrent()
+	c.Set("1", "aaaa")
+	c.Set("2", "bbb")
+	c.Set("3", "cc") // evicts 1
+	Equal(t, c.Get("1"), optionext.None[string]())
+	c.Set("2", "bbbbbbbb") // rejected, removing 2
+	Equal(t, c.Get("2"), optionext.None[string]())
+	Equal(t, c.Get("3"), optionext.Some("cc"))
+	stats := c.Stats()
+	Equal(t, stats.Weight, 2)
+	Equal(t, stats.Rejections, uint(1))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(1))
+
+	PanicMatches(t, func() {
+		c.Set("4", "")
+	}, "Weigher weight must be a positive value")
+}
+
+func TestLRUConcurrentCacheGhosts(t *testing.T) {
+	c := New[string, int](1).Ghosts(10).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("2", 2) // evicts 1
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, c.Get("1"), optionext.None[int]())
+	c.Set("3", 3) // evicts 2
+	c.Set("2", 2) // evicts 3 and forgets ghost 2
+	Equal(t, c.Get("3"), optionext.None[int]())
+	stats := c.Stats()
+	Equal(t, stats.Misses, uint(3))
+	Equal(t, stats.EvictionMisses, uint(2))
+}
+
+func TestLRUConcurrentCacheHeavyHitters(t *testing.T) {
+	Equal(t, New[string, int](1).BuildConcurrent().TopK(1), []KeyCount[string](nil))
+
+	c := New[string, int](1).HeavyHitters(10).BuildConcurrent()
+	c.Set("1", 1)
+	c.Get("1")
+	c.Get("1")
+	c.Get("2")
+	Equal(t, c.TopK(5), []KeyCount[string]{{Key: "1", Count: 2}, {Key: "2", Count: 1}})
+}
+
+func TestLRUConcurrentCacheLogger(t *testing.T) {
+	var buf bytes.Buffer
+	c := New[string, int](1).Logger(newTestLogger(&buf)).BuildConcurrent()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Remove("2")
+	c.Set("3", 3)
+	c.Resize(2)
+	c.Clear()
+	Equal(t, buf.String(), `level=DEBUG msg="cache evicted" key=1 reason=capacity
+level=DEBUG msg="cache evicted" key=2 reason=removed
+level=DEBUG msg="cache resized" from=1 to=2 evicted=0
+level=DEBUG msg="cache cleared" removed=1
+`)
+}
+
+func TestLRUConcurrentCacheConcurrency(t *testing.T) {
+	c := New[int, int](100).BuildConcurrent()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1_000; i++ {
+				if i%10 == g {
+					c.Set(i, i)
+				}
+				if option := c.Get(i % 200); option.IsSome() && option.Unwrap() != i%200 {
+					panic("undefined behaviour")
+				}
+				if i%100 == g {
+					c.Remove(i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	Equal(t, c.Stats().Len <= 100, true)
+}
+
+func BenchmarkLRUConcurrentCacheGetsOnlyParallel(b *testing.B) {
+	cache := New[string, string](100).BuildConcurrent()
+	cache.Set("a", "b")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			option := cache.Get("a")
+			if option.IsNone() || option.Unwrap() != "b" {
+				panic("undefined behaviour")
+			}
+		}
+	})
+}
+
+func BenchmarkLRUConcurrentCacheGetSetParallel(b *testing.B) {
+	cache := New[string, string](100).BuildConcurrent()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			j := strconv.Itoa(i % 100)
+			cache.Set(j, "b")
+			option := cache.Get(j)
+			if option.IsNone() || option.Unwrap() != "b" {
+				panic("undefined behaviour")
+			}
+			i++
+		}
+	})
+}
+
+func TestLRUConcurrentCacheConformance(t *testing.T) {
+	cachetest.RunConformance(t, func(cfg cachetest.Config) cachetest.Cache[Stats] {
+		return New[int, int](cfg.Capacity).MaxAge(cfg.MaxAge).Clock(cfg.Clock).BuildConcurrent()
+	}, cachetest.Options{Policy: cachetest.Any})
+}
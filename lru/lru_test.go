@@ -1,9 +1,13 @@
 package lru
 
 import (
+	"bytes"
+	"errors"
 	. "github.com/go-playground/assert/v2"
 	syncext "github.com/go-playground/pkg/v5/sync"
+	timeext "github.com/go-playground/pkg/v5/time"
 	optionext "github.com/go-playground/pkg/v5/values/option"
+	"log/slog"
 	"strconv"
 	"testing"
 	"time"
@@ -40,6 +44,10 @@ func TestLRUBasics(t *testing.T) {
 	Equal(t, stats.Gets, uint(5))
 	Equal(t, stats.Sets, uint(5))
 	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(1))
+	Equal(t, stats.Expirations, uint(0))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.Replacements, uint(1))
 	Equal(t, stats.Len, 2)
 	Equal(t, stats.Capacity, 3)
 
@@ -55,6 +63,7 @@ func TestLRUBasics(t *testing.T) {
 	Equal(t, stats.Gets, uint(0))
 	Equal(t, stats.Sets, uint(0))
 	Equal(t, stats.Evictions, uint(0))
+	Equal(t, stats.Removals, uint(0))
 	Equal(t, stats.Len, 0)
 	Equal(t, stats.Capacity, 3)
 }
@@ -68,6 +77,511 @@ func TestLRUMaxAge(t *testing.T) {
 	Equal(t, c.Get("1"), optionext.None[int]())
 	Equal(t, c.list.Len(), 0)
 	Equal(t, c.stats.Evictions, uint(1))
+	Equal(t, c.stats.Expirations, uint(1))
+	Equal(t, c.stats.CapacityEvictions, uint(0))
+}
+
+func TestLRUResize(t *testing.T) {
+	c := New[string, int](4).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.Set("4", 4)
+	Equal(t, c.Get("1"), optionext.Some(1))
+
+	c.Resize(2)
+	Equal(t, c.stats.Capacity, 2)
+	Equal(t, c.list.Len(), 2)
+	Equal(t, c.stats.Evictions, uint(2))
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("3"), optionext.None[int]())
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("4"), optionext.Some(4))
+
+	c.Resize(3)
+	c.Set("5", 5)
+	Equal(t, c.list.Len(), 3)
+	Equal(t, c.stats.Evictions, uint(2))
+
+	PanicMatches(t, func() {
+		c.Resize(-1)
+	}, "Resize is not permitted to be a negative value")
+}
+
+func TestLRUSetMissing(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.SetMissing("1", time.Nanosecond)
+	c.SetMissing("2", 0)
+	c.Set("3", 3)
+
+	result, missing := c.Lookup("2")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, true)
+	Equal(t, c.Get("2"), optionext.None[int]())
+
+	result, missing = c.Lookup("3")
+	Equal(t, result, optionext.Some(3))
+	Equal(t, missing, false)
+
+	result, missing = c.Lookup("4")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, false)
+
+	// negative entries use their own maxAge
+	time.Sleep(time.Second) // for windows :(
+	result, missing = c.Lookup("1")
+	Equal(t, result, optionext.None[int]())
+	Equal(t, missing, false)
+
+	// replacing a negative entry
+	c.Set("2", 2)
+	result, missing = c.Lookup("2")
+	Equal(t, result, optionext.Some(2))
+	Equal(t, missing, false)
+
+	stats := c.Stats()
+	Equal(t, stats.Sets, uint(4))
+	Equal(t, stats.Gets, uint(6))
+	Equal(t, stats.Hits, uint(2))
+	Equal(t, stats.NegativeHits, uint(2))
+	Equal(t, stats.Misses, uint(1))
+	Equal(t, stats.Evictions, uint(1))
+	Equal(t, stats.Len, 2)
+
+	PanicMatches(t, func() {
+		c.SetMissing("1", -time.Second)
+	}, "SetMissing maxAge is not permitted to be a negative value")
+}
+
+func TestLRUGetEntry(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.SetMissing("3", time.Minute)
+
+	option := c.GetEntry("1")
+	Equal(t, option.IsSome(), true)
+	e := option.Unwrap()
+	Equal(t, e.Value, 1)
+	Equal(t, e.Position, 2)
+	Equal(t, e.Age < time.Minute, true)
+	Equal(t, e.TTL > 59*time.Minute && e.TTL <= time.Hour, true)
+
+	Equal(t, c.GetEntry("3"), optionext.None[Entry[int]]())
+	Equal(t, c.GetEntry("4"), optionext.None[Entry[int]]())
+
+	// GetEntry is not an access
+	Equal(t, c.GetEntry("1").Unwrap().Position, 2)
+	c.Set("4", 4) // evicts 1
+	Equal(t, c.GetEntry("1"), optionext.None[Entry[int]]())
+	Equal(t, c.GetEntry("4").Unwrap().Position, 0)
+	Equal(t, c.GetEntry("2").Unwrap().Position, 2)
+
+	stats := c.Stats()
+	Equal(t, stats.Gets, uint(0))
+
+	now := timeext.Instant(1)
+	c = New[string, int](3).MaxAge(time.Hour).Clock(func() timeext.Instant { return now }).Build()
+	c.Set("1", 1)
+	now += timeext.Instant(time.Minute)
+	e = c.GetEntry("1").Unwrap()
+	Equal(t, e.Inserted, timeext.Instant(1))
+	Equal(t, e.Age, time.Minute)
+	Equal(t, e.TTL, 59*time.Minute)
+
+	// only aged with a max age
+	var calls int
+	c = New[string, int](3).Clock(func() timeext.Instant {
+		calls++
+		return now
+	}).Build()
+	c.Set("1", 1)
+	e = c.GetEntry("1").Unwrap()
+	Equal(t, calls, 0)
+	Equal(t, e.Inserted, timeext.Instant(0))
+	Equal(t, e.Age, time.Duration(0))
+	Equal(t, e.TTL, time.Duration(0))
+}
+
+func TestLRUOnEvict(t *testing.T) {
+	type evicted struct {
+		key    string
+		value  int
+		reason Reason
+	}
+	var evictions []evicted
+
+	c := New[string, int](2).MaxAge(time.Hour).OnEvict(func(key string, value int, reason Reason) {
+		evictions = append(evictions, evicted{key: key, value: value, reason: reason})
+	}).Build()
+	c.Set("1", 1)
+	c.Set("1", 10)
+	c.Set("2", 2)
+	c.Set("3", 3)
+	c.SetMissing("4", time.Nanosecond)
+	c.Remove("3")
+	c.Set("5", 5)
+	c.SetMissing("5", time.Nanosecond)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("5"), optionext.None[int]())
+	c.Set("6", 6)
+	c.Clear()
+
+	c = New[string, int](2).MaxAge(time.Nanosecond).OnEvict(func(key string, value int, reason Reason) {
+		evictions = append(evictions, evicted{key: key, value: value, reason: reason})
+	}).Build()
+	c.Set("7", 7)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("7"), optionext.None[int]())
+
+	Equal(t, evictions, []evicted{
+		{key: "1", value: 1, reason: Replaced},
+		{key: "1", value: 10, reason: Capacity},
+		{key: "2", value: 2, reason: Capacity},
+		{key: "3", value: 3, reason: Removed},
+		{key: "5", value: 5, reason: Replaced},
+		{key: "6", value: 6, reason: Removed},
+		{key: "7", value: 7, reason: Expired},
+	})
+	Equal(t, Capacity.String(), "capacity")
+	Equal(t, Expired.String(), "expired")
+}
+
+// conn is a value implementing io.Closer, failing to close when bad.
+type conn struct {
+	name   string
+	bad    bool
+	closed int
+}
+
+func (c *conn) Close() error {
+	c.closed++
+	if c.bad {
+		return errors.New(c.name + " failed to close")
+	}
+	return nil
+}
+
+func TestLRUCloseValues(t *testing.T) {
+	var now timeext.Instant
+	var errs []string
+	c := New[string, *conn](2).MaxAge(time.Minute).Clock(func() timeext.Instant { return now }).
+		CloseValues(func(key string, err error) {
+			errs = append(errs, key+": "+err.Error())
+		}).Build()
+
+	a, b, d, e := &conn{name: "a"}, &conn{name: "b", bad: true}, &conn{name: "d"}, &conn{name: "e"}
+	c.Set("a", a)
+	c.Set("a", a) // same value is not closed
+	Equal(t, a.closed, 0)
+	c.Set("b", b)
+	c.Set("a", d) // replaced
+	Equal(t, a.closed, 1)
+	c.Set("e", e) // evicts b
+	Equal(t, b.closed, 1)
+	Equal(t, errs, []string{"b: b failed to close"})
+
+	c.Remove("a")
+	Equal(t, d.closed, 1)
+	now = timeext.Instant(2 * time.Minute)
+	Equal(t, c.Get("e").IsNone(), true) // expired
+	Equal(t, e.closed, 1)
+
+	f, g := &conn{name: "f"}, &conn{name: "g"}
+	c.Set("f", f)
+	c.Set("g", g)
+	c.SetMissing("g", 0) // replaced by no value
+	Equal(t, g.closed, 1)
+	c.Resize(1)
+	c.Clear()
+	Equal(t, f.closed, 1)
+	Equal(t, []int{a.closed, b.closed, d.closed, e.closed, f.closed, g.closed}, []int{1, 1, 1, 1, 1, 1})
+
+	// values not implementing io.Closer are ignored.
+	New[string, int](1).CloseValues(nil).Build().Set("1", 1)
+}
+
+func TestLRUAcquire(t *testing.T) {
+	var now timeext.Instant
+	var evictions []string
+	c := New[string, *conn](2).MaxAge(time.Minute).Clock(func() timeext.Instant { return now }).
+		OnEvict(func(key string, value *conn, reason Reason) {
+			evictions = append(evictions, key+" "+reason.String())
+		}).CloseValues(nil).Build()
+	a, b, d, e := &conn{name: "a"}, &conn{name: "b"}, &conn{name: "d"}, &conn{name: "e"}
+	c.Set("a", a)
+	c.Set("b", b)
+
+	h, found := c.Acquire("a")
+	Equal(t, found, true)
+	Equal(t, h.Value(), a)
+	_, found = c.Acquire("z")
+	Equal(t, found, false)
+	c.Get("b")    // a is now the least recently used
+	c.Set("d", d) // evicts b rather than the pinned a
+	Equal(t, b.closed, 1)
+	stats := c.Stats()
+	Equal(t, stats.Len, 2)
+	Equal(t, stats.Pinned, 1)
+	Equal(t, stats.PinnedWeight, 1)
+
+	// capacity is exceeded while every entry is pinned, until released.
+	h2, _ := c.Acquire("d")
+	c.Resize(1)
+	Equal(t, c.Stats().Len, 2)
+	h2.Release()
+	h2.Release() // noop
+	Equal(t, d.closed, 1)
+	stats = c.Stats()
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Pinned, 1)
+	c.Resize(2)
+	c.Set("e", e)
+
+	// replaced while pinned keeps its value until released.
+	a2 := &conn{name: "a2"}
+	c.Set("a", a2)
+	Equal(t, h.Value(), a)
+	Equal(t, c.Get("a").Unwrap(), a2)
+	Equal(t, a.closed, 0)
+	h.Release()
+	Equal(t, a.closed, 1)
+
+	// removed while pinned is finalized upon release.
+	h, _ = c.Acquire("e")
+	c.Remove("e")
+	Equal(t, c.Get("e").IsNone(), true)
+	Equal(t, e.closed, 0)
+	Equal(t, c.Stats().Pinned, 1)
+	h.Release()
+	Equal(t, e.closed, 1)
+
+	// as is expired or cleared.
+	h, _ = c.Acquire("a")
+	h2 = h
+	now = timeext.Instant(2 * time.Minute)
+	Equal(t, c.Get("a").IsNone(), true)
+	c.Clear()
+	Equal(t, a2.closed, 0)
+	h2.Release()
+	h.Release() // noop, released via copy
+	Equal(t, a2.closed, 1)
+
+	Equal(t, evictions, []string{"b capacity", "d capacity", "a replaced", "e removed", "a expired"})
+	stats = c.Stats()
+	Equal(t, stats.Len, 0)
+	Equal(t, stats.Pinned, 0)
+	Equal(t, stats.PinnedWeight, 0)
+	Handle[int]{}.Release() // noop
+}
+
+func TestLRUWeigher(t *testing.T) {
+	c := New[string, string](10).Weigher(func(key string, value string) int {
+		return len(value)
+	}).Build()
+	c.Set("1", "aaaa")
+	c.Set("2", "bbbb")
+	Equal(t, c.weight, 8)
+	c.Set("3", "cc") // within capacity
+	Equal(t, c.list.Len(), 3)
+	c.Set("4", "d") // evicts 1
+	Equal(t, c.Get("1"), optionext.None[string]())
+	Equal(t, c.weight, 7)
+
+	// replacing with a heavier value
+	c.Set("4", "dddddd") // evicts 2
+	Equal(t, c.Get("2"), optionext.None[string]())
+	Equal(t, c.Get("4"), optionext.Some("dddddd"))
+	c.SetMissing("5", time.Hour)
+	Equal(t, c.weight, 9)
+
+	c.Resize(7)
+	stats := c.Stats()
+	Equal(t, stats.Weight <= 7, true)
+	Equal(t, stats.CapacityEvictions, uint(3))
+
+	c.Remove("4")
+	c.Clear()
+	Equal(t, c.Stats().Weight, 0)
+
+	// heavier than the capacity
+	c.Set("6", "fff")
+	c.Set("7", "ggg")
+	c.Set("6", "hhhhhhhh") // rejected, removing 6
+	Equal(t, c.Get("6"), optionext.None[string]())
+	Equal(t, c.Get("7"), optionext.Some("ggg"))
+	stats = c.Stats()
+	Equal(t, stats.Weight, 3)
+	Equal(t, stats.Rejections, uint(1))
+	Equal(t, stats.Removals, uint(1))
+	Equal(t, stats.CapacityEvictions, uint(0))
+
+	PanicMatches(t, func() {
+		c.Set("8", "")
+	}, "Weigher weight must be a positive value")
+}
+
+func TestLRUGhosts(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).Ghosts(0)
+	}, "Ghosts must be a positive value")
+
+	c := New[string, int](2).Ghosts(2).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3) // evicts 1
+	c.Set("4", 4) // evicts 2
+	c.Set("5", 5) // evicts 3, forgetting ghost 1
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("2"), optionext.None[int]()) // only counted once
+	Equal(t, c.Get("6"), optionext.None[int]())
+
+	// setting an evicted key forgets its ghost
+	c.Set("3", 3)
+	Equal(t, c.ghosts.list.Len(), 1)
+
+	// removals are not ghosted
+	c.Remove("3")
+	Equal(t, c.Get("3"), optionext.None[int]())
+
+	stats := c.Stats()
+	Equal(t, stats.Misses, uint(5))
+	Equal(t, stats.EvictionMisses, uint(1))
+}
+
+func TestLRUHeavyHitters(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).HeavyHitters(0)
+	}, "HeavyHitters must be a positive value")
+	PanicMatches(t, func() {
+		New[string, int](3).Build().TopK(0)
+	}, "TopK n must be a positive value")
+	Equal(t, New[string, int](3).Build().TopK(1), []KeyCount[string](nil))
+
+	c := New[string, int](2).HeavyHitters(2).Build()
+	c.Set("1", 1)
+	for i := 0; i < 3; i++ {
+		c.Get("1")
+	}
+	c.Get("2") // misses count too
+	c.Get("2")
+	c.Get("3") // replaces 2, inheriting its count as error
+	Equal(t, c.TopK(2), []KeyCount[string]{{Key: "1", Count: 3}, {Key: "3", Count: 3, Error: 2}})
+	Equal(t, c.TopK(1), []KeyCount[string]{{Key: "1", Count: 3}})
+
+	// a skewed stream always reports the heaviest keys, each key i being gotten 2^i times.
+	c = New[string, int](10).HeavyHitters(8).Build()
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 1<<i; j++ {
+			c.Get(strconv.Itoa(i))
+		}
+	}
+	top := c.TopK(3)
+	Equal(t, len(top), 3)
+	for i, kc := range top {
+		Equal(t, kc.Key, strconv.Itoa(11-i))
+		Equal(t, kc.Count-kc.Error <= 1<<(11-i), true)
+		Equal(t, kc.Count >= 1<<(11-i), true)
+	}
+}
+
+func TestLRURange(t *testing.T) {
+	c := New[string, int](5).MaxAge(time.Hour).Build()
+	Equal(t, c.MaxAge(), time.Hour)
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.SetMissing("3", 0)
+	c.SetWithMaxAge("4", 4, time.Nanosecond)
+	c.Set("5", 5)
+	c.Get("1")
+	time.Sleep(time.Second) // for windows :(
+
+	var keys []string
+	var values, positions []int
+	c.Range(func(key string, entry Entry[int]) bool {
+		keys = append(keys, key)
+		values = append(values, entry.Value)
+		positions = append(positions, entry.Position)
+		return true
+	})
+	Equal(t, keys, []string{"1", "5", "2"})
+	Equal(t, values, []int{1, 5, 2})
+	Equal(t, positions, []int{0, 1, 4})
+
+	keys = nil
+	c.Range(func(key string, entry Entry[int]) bool {
+		keys = append(keys, key)
+		return false
+	})
+	Equal(t, keys, []string{"1"})
+	Equal(t, c.Stats().Gets, uint(1))
+}
+
+func TestLRULogger(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](3).LogSampling(0)
+	}, "LogSampling must be a positive value")
+
+	var buf bytes.Buffer
+	c := New[string, int](2).Logger(newTestLogger(&buf)).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("2", 20) // replacements are not logged
+	c.Set("3", 3)
+	c.SetWithMaxAge("4", 4, time.Nanosecond)
+	time.Sleep(time.Second) // for windows :(
+	c.Get("4")
+	c.Remove("3")
+	c.Set("5", 5)
+	c.Resize(0)
+	c.Clear()
+	Equal(t, buf.String(), `level=DEBUG msg="cache evicted" key=1 reason=capacity
+level=DEBUG msg="cache evicted" key=2 reason=capacity
+level=DEBUG msg="cache evicted" key=4 reason=expired
+level=DEBUG msg="cache evicted" key=3 reason=removed
+level=DEBUG msg="cache evicted" key=5 reason=capacity
+level=DEBUG msg="cache resized" from=2 to=0 evicted=1
+level=DEBUG msg="cache cleared" removed=0
+`)
+
+	// sampled
+	buf.Reset()
+	c = New[string, int](1).Logger(newTestLogger(&buf)).LogSampling(3).Build()
+	for i := 0; i < 7; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	Equal(t, buf.String(), `level=DEBUG msg="cache evicted" key=0 reason=capacity sampling=3
+level=DEBUG msg="cache evicted" key=3 reason=capacity sampling=3
+`)
+
+	// nothing is logged above debug level
+	buf.Reset()
+	c = New[string, int](1).Logger(slog.New(slog.NewTextHandler(&buf, nil))).Build()
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Clear()
+	Equal(t, buf.Len(), 0)
+}
+
+func TestLRUSetWithMaxAge(t *testing.T) {
+	c := New[string, int](3).MaxAge(time.Hour).Build()
+	c.SetWithMaxAge("1", 1, time.Nanosecond)
+	c.Set("2", 2)
+	time.Sleep(time.Second) // for windows :(
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, c.Get("2"), optionext.Some(2))
+	Equal(t, c.stats.Expirations, uint(1))
+
+	// replacing resets to the caches MaxAge
+	c.SetWithMaxAge("2", 2, time.Minute)
+	Equal(t, c.GetEntry("2").Unwrap().TTL <= time.Minute, true)
+	c.Set("2", 2)
+	Equal(t, c.GetEntry("2").Unwrap().TTL > time.Minute, true)
+
+	PanicMatches(t, func() {
+		c.SetWithMaxAge("1", 1, -time.Second)
+	}, "SetWithMaxAge maxAge is not permitted to be a negative value")
 }
 
 func BenchmarkLRUCacheWithMaxAge(b *testing.B) {
@@ -142,3 +656,16 @@ func BenchmarkLRUCacheGetSetParallel(b *testing.B) {
 		}
 	})
 }
+
+// newTestLogger returns a debug level logger writing records without their time to buf.
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
@@ -0,0 +1,483 @@
+package memcached
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	maxKeyLength = 250
+
+	// relativeExptimeLimit is the largest exptime, 30 days in seconds, treated as relative rather than a unix time.
+	relativeExptimeLimit = 60 * 60 * 24 * 30
+)
+
+var (
+	crlf            = []byte("\r\n")
+	errLineTooLong  = errors.New("line too long")
+	msgBadFormat    = "CLIENT_ERROR bad command line format"
+	msgBadChunk     = "CLIENT_ERROR bad data chunk"
+	msgTooLarge     = "SERVER_ERROR object too large for cache"
+	msgOutOfMemory  = "SERVER_ERROR out of memory storing object"
+	msgNonNumeric   = "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	msgInvalidDelta = "CLIENT_ERROR invalid numeric delta argument"
+)
+
+// conn is a single client connection.
+type conn[S any] struct {
+	s *Server[S]
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (s *Server[S]) serve(nc net.Conn) {
+	s.currConnections.Add(1)
+	s.totalConnections.Add(1)
+	defer s.currConnections.Add(-1)
+
+	c := &conn[S]{
+		s: s,
+		r: bufio.NewReaderSize(nc, 4096),
+		w: bufio.NewWriter(nc),
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				c.reply("CLIENT_ERROR line too long")
+				_ = c.w.Flush()
+			}
+			return
+		}
+		args := bytes.Fields(line)
+		if len(args) == 0 {
+			c.reply("ERROR")
+		} else if !c.handle(string(args[0]), args[1:]) {
+			_ = c.w.Flush()
+			return
+		}
+		if c.r.Buffered() == 0 {
+			if err = c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *conn[S]) readLine() ([]byte, error) {
+	line, err := c.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, errLineTooLong
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func (c *conn[S]) reply(line string) {
+	_, _ = c.w.WriteString(line)
+	_, _ = c.w.Write(crlf)
+}
+
+// handle executes a command returning false if the connection should be closed.
+func (c *conn[S]) handle(cmd string, args [][]byte) bool {
+	switch cmd {
+	case "get":
+		return c.get(args, false)
+	case "gets":
+		return c.get(args, true)
+	case "set", "add", "replace", "append", "prepend", "cas":
+		return c.store(cmd, args)
+	case "delete":
+		c.delete(args)
+	case "incr", "decr":
+		c.incr(cmd == "incr", args)
+	case "touch":
+		c.touch(args)
+	case "stats":
+		c.stats(args)
+	case "flush_all":
+		c.flushAll(args)
+	case "version":
+		c.reply("VERSION " + Version)
+	case "verbosity":
+		c.replyUnless(noreply(args), "OK")
+	case "quit":
+		return false
+	default:
+		c.reply("ERROR")
+	}
+	return true
+}
+
+// noreply returns if the last argument is noreply, removing it from the arguments.
+func noreply(args [][]byte) bool {
+	return len(args) > 0 && string(args[len(args)-1]) == "noreply"
+}
+
+func (c *conn[S]) replyUnless(quiet bool, line string) {
+	if !quiet {
+		c.reply(line)
+	}
+}
+
+func validKey(key []byte) bool {
+	return len(key) > 0 && len(key) <= maxKeyLength
+}
+
+// maxAge converts an exptime into the maxAge and expiry time to store an item with. It returns false if the item
+// has already expired.
+func maxAge(exptime int64) (time.Duration, time.Time, bool) {
+	switch {
+	case exptime == 0:
+		return 0, time.Time{}, true
+	case exptime < 0:
+		return 0, time.Time{}, false
+	case exptime > relativeExptimeLimit:
+		expires := time.Unix(exptime, 0)
+		d := time.Until(expires)
+		return d, expires, d > 0
+	default:
+		d := time.Duration(exptime) * time.Second
+		return d, time.Now().Add(d), true
+	}
+}
+
+// remaining returns the maxAge remaining for an item and false if it has expired.
+func remaining(item *Item) (time.Duration, bool) {
+	if item.expires.IsZero() {
+		return 0, true
+	}
+	d := time.Until(item.expires)
+	return d, d > 0
+}
+
+func (c *conn[S]) get(args [][]byte, withCAS bool) bool {
+	if len(args) == 0 {
+		c.reply("ERROR")
+		return true
+	}
+	for _, key := range args {
+		if !validKey(key) {
+			c.reply(msgBadFormat)
+			return true
+		}
+	}
+	for _, key := range args {
+		option := c.s.cache.Get(string(key))
+		if option.IsNone() {
+			continue
+		}
+		item := option.Unwrap()
+		_, _ = c.w.WriteString("VALUE ")
+		_, _ = c.w.Write(key)
+		_, _ = c.w.WriteString(" " + strconv.FormatUint(uint64(item.Flags), 10) + " " + strconv.Itoa(len(item.Value)))
+		if withCAS {
+			_, _ = c.w.WriteString(" " + strconv.FormatUint(item.CAS, 10))
+		}
+		_, _ = c.w.Write(crlf)
+		_, _ = c.w.Write(item.Value)
+		_, _ = c.w.Write(crlf)
+	}
+	c.reply("END")
+	return true
+}
+
+func (c *conn[S]) store(cmd string, args [][]byte) bool {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	want := 4
+	if cmd == "cas" {
+		want = 5
+	}
+	if len(args) != want {
+		c.reply(msgBadFormat)
+		return true
+	}
+	flags, err1 := strconv.ParseUint(string(args[1]), 10, 32)
+	exptime, err2 := strconv.ParseInt(string(args[2]), 10, 64)
+	size, err3 := strconv.Atoi(string(args[3]))
+	var unique uint64
+	var err4 error
+	if cmd == "cas" {
+		unique, err4 = strconv.ParseUint(string(args[4]), 10, 64)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || size < 0 || !validKey(args[0]) {
+		c.reply(msgBadFormat)
+		return true
+	}
+	key := string(args[0])
+	if size > c.s.maxItemSize {
+		// reply before swallowing the data, which may never arrive in full, keeping the connection in sync.
+		c.replyUnless(quiet, msgTooLarge)
+		if err := c.w.Flush(); err != nil {
+			return false
+		}
+		n := int64(size) + 2
+		if n < 0 {
+			// overflowed, the data can never be swallowed.
+			return false
+		}
+		_, err := io.CopyN(io.Discard, c.r, n)
+		return err == nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return false
+	}
+	if !bytes.Equal(data[size:], crlf) {
+		if data[size+1] != '\n' {
+			// discard the remainder of the malformed data line
+			if _, err := c.r.ReadSlice('\n'); err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+				return false
+			}
+		}
+		c.reply(msgBadChunk)
+		return true
+	}
+	data = data[:size]
+
+	c.s.mu.Lock()
+	result := c.s.set(cmd, key, uint32(flags), exptime, data, unique)
+	c.s.mu.Unlock()
+	c.replyUnless(quiet, result)
+	return true
+}
+
+// set performs a storage command returning the response, it must be called with the write lock held.
+func (s *Server[S]) set(cmd, key string, flags uint32, exptime int64, data []byte, unique uint64) string {
+	var option optionext.Option[*Item]
+	if cmd != "set" {
+		option = s.cache.Get(key)
+	}
+	switch cmd {
+	case "add":
+		if option.IsSome() {
+			return "NOT_STORED"
+		}
+	case "replace", "append", "prepend":
+		if option.IsNone() {
+			return "NOT_STORED"
+		}
+	case "cas":
+		if option.IsNone() {
+			return "NOT_FOUND"
+		}
+		if option.Unwrap().CAS != unique {
+			return "EXISTS"
+		}
+	}
+
+	if cmd == "append" || cmd == "prepend" {
+		current := option.Unwrap()
+		d, ok := remaining(current)
+		if !ok {
+			return "NOT_STORED"
+		}
+		value := make([]byte, 0, len(current.Value)+len(data))
+		if cmd == "append" {
+			value = append(append(value, current.Value...), data...)
+		} else {
+			value = append(append(value, data...), current.Value...)
+		}
+		if len(value) > s.maxItemSize {
+			return msgTooLarge
+		}
+		s.cache.SetWithMaxAge(key, &Item{Flags: current.Flags, Value: value, CAS: s.cas.Add(1), expires: current.expires}, d)
+		if s.rejected() {
+			return msgOutOfMemory
+		}
+		return "STORED"
+	}
+
+	d, expires, ok := maxAge(exptime)
+	if !ok {
+		s.cache.Remove(key)
+		return "STORED"
+	}
+	s.cache.SetWithMaxAge(key, &Item{Flags: flags, Value: data, CAS: s.cas.Add(1), expires: expires}, d)
+	if s.rejected() {
+		return msgOutOfMemory
+	}
+	return "STORED"
+}
+
+func (c *conn[S]) delete(args [][]byte) {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	// a trailing 0 is permitted for compatibility with older clients
+	if len(args) == 2 && string(args[1]) == "0" {
+		args = args[:1]
+	}
+	if len(args) != 1 || !validKey(args[0]) {
+		c.reply(msgBadFormat)
+		return
+	}
+	key := string(args[0])
+
+	c.s.mu.Lock()
+	found := c.s.cache.Get(key).IsSome()
+	if found {
+		c.s.cache.Remove(key)
+	}
+	c.s.mu.Unlock()
+
+	if found {
+		c.replyUnless(quiet, "DELETED")
+	} else {
+		c.replyUnless(quiet, "NOT_FOUND")
+	}
+}
+
+func (c *conn[S]) incr(incr bool, args [][]byte) {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	if len(args) != 2 || !validKey(args[0]) {
+		c.reply(msgBadFormat)
+		return
+	}
+	delta, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		c.reply(msgInvalidDelta)
+		return
+	}
+	key := string(args[0])
+
+	c.s.mu.Lock()
+	result := c.s.incr(incr, key, delta)
+	c.s.mu.Unlock()
+	c.replyUnless(quiet, result)
+}
+
+// incr performs an incr or decr returning the response, it must be called with the write lock held. Increments wrap
+// at 64 bits while decrements stop at zero, as memcached does.
+func (s *Server[S]) incr(incr bool, key string, delta uint64) string {
+	option := s.cache.Get(key)
+	if option.IsNone() {
+		return "NOT_FOUND"
+	}
+	current := option.Unwrap()
+	d, ok := remaining(current)
+	if !ok {
+		return "NOT_FOUND"
+	}
+	n, err := strconv.ParseUint(string(bytes.TrimSpace(current.Value)), 10, 64)
+	if err != nil {
+		return msgNonNumeric
+	}
+	if incr {
+		n += delta
+	} else if delta > n {
+		n = 0
+	} else {
+		n -= delta
+	}
+	value := strconv.FormatUint(n, 10)
+	s.cache.SetWithMaxAge(key, &Item{Flags: current.Flags, Value: []byte(value), CAS: s.cas.Add(1), expires: current.expires}, d)
+	return value
+}
+
+func (c *conn[S]) touch(args [][]byte) {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	if len(args) != 2 || !validKey(args[0]) {
+		c.reply(msgBadFormat)
+		return
+	}
+	exptime, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		c.reply(msgBadFormat)
+		return
+	}
+	key := string(args[0])
+
+	c.s.mu.Lock()
+	option := c.s.cache.Get(key)
+	if option.IsSome() {
+		current := option.Unwrap()
+		if d, expires, ok := maxAge(exptime); ok {
+			c.s.cache.SetWithMaxAge(key, &Item{Flags: current.Flags, Value: current.Value, CAS: current.CAS, expires: expires}, d)
+		} else {
+			c.s.cache.Remove(key)
+		}
+	}
+	c.s.mu.Unlock()
+
+	if option.IsSome() {
+		c.replyUnless(quiet, "TOUCHED")
+	} else {
+		c.replyUnless(quiet, "NOT_FOUND")
+	}
+}
+
+func (c *conn[S]) stats(args [][]byte) {
+	if len(args) > 0 {
+		c.reply("ERROR")
+		return
+	}
+	now := time.Now()
+	stat := func(name, value string) {
+		c.reply("STAT " + name + " " + value)
+	}
+	stat("pid", strconv.Itoa(os.Getpid()))
+	stat("uptime", strconv.FormatInt(int64(now.Sub(c.s.started)/time.Second), 10))
+	stat("time", strconv.FormatInt(now.Unix(), 10))
+	stat("version", Version)
+	stat("curr_connections", strconv.FormatInt(c.s.currConnections.Load(), 10))
+	stat("total_connections", strconv.FormatUint(c.s.totalConnections.Load(), 10))
+	stat("cmd_flush", strconv.FormatUint(c.s.flushes.Load(), 10))
+	for _, kv := range c.s.cacheStats() {
+		stat(kv[0], kv[1])
+	}
+	c.reply("END")
+}
+
+func (c *conn[S]) flushAll(args [][]byte) {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	var delay int64
+	if len(args) == 1 {
+		var err error
+		if delay, err = strconv.ParseInt(string(args[0]), 10, 64); err != nil || delay < 0 {
+			c.reply(msgBadFormat)
+			return
+		}
+	} else if len(args) > 1 {
+		c.reply(msgBadFormat)
+		return
+	}
+	c.s.flushes.Add(1)
+	if delay == 0 {
+		c.s.flush()
+	} else {
+		time.AfterFunc(time.Duration(delay)*time.Second, c.s.flush)
+	}
+	c.replyUnless(quiet, "OK")
+}
+
+func (s *Server[S]) flush() {
+	s.mu.Lock()
+	// accumulate the Stats delta first as clearing resets it.
+	s.stats.Sum(s.cache.Stats())
+	s.cache.Clear()
+	s.mu.Unlock()
+}
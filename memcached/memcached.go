@@ -0,0 +1,165 @@
+package memcached
+
+import (
+	"github.com/go-playground/cache/internal/netserver"
+	"github.com/go-playground/cache/internal/stats"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Version is reported by the version and stats commands.
+const Version = "1.6.0-go-playground-cache"
+
+// ErrServerClosed is returned by Serve and ListenAndServe after the Server has been closed.
+var ErrServerClosed = netserver.ErrServerClosed
+
+// Cache is the set of functions required to store items, satisfied by the lru and lfu ThreadSafeCache using string
+// keys and *Item values, S being their Stats type.
+//
+// Build the cache with Weigh as its Weigher to bound it by bytes, as memcached does, rather than by item count.
+type Cache[S any] interface {
+	Get(key string) optionext.Option[*Item]
+	SetWithMaxAge(key string, value *Item, maxAge time.Duration)
+	Remove(key string)
+	Clear()
+	Stats() S
+}
+
+// Item is a stored memcached item.
+type Item struct {
+	// Flags are the opaque client flags stored alongside the value.
+	Flags uint32
+
+	// Value is the items data.
+	Value []byte
+
+	// CAS is the items unique version used by the gets and cas commands.
+	CAS uint64
+
+	// expires is when the item expires or zero if it only expires according to the caches MaxAge.
+	expires time.Time
+}
+
+// Weigh returns the approximate size in bytes of an item, for use as the caches Weigher.
+func Weigh(key string, item *Item) int {
+	return len(key) + len(item.Value)
+}
+
+type builder[S any] struct {
+	server *Server[S]
+}
+
+// New initializes a builder to create a Server speaking the memcached text protocol backed by the provided cache.
+//
+// The Server accumulates the caches Stats delta on every stats command to report totals, as memcached does, and after
+// every set to detect items the cache rejected for weighing more than its capacity, and so nothing else should call
+// the caches Stats function. Commands which must check for an existing item, such as add,
+// cas, incr and delete, count as gets in the caches Stats.
+func New[S any](cache Cache[S]) *builder[S] {
+	return &builder[S]{
+		server: &Server[S]{
+			cache:       cache,
+			maxItemSize: 1 << 20,
+		},
+	}
+}
+
+// MaxItemSize sets the largest value, in bytes, which may be stored.
+//
+// Default is 1MiB.
+func (b *builder[S]) MaxItemSize(size int) *builder[S] {
+	if size <= 0 {
+		panic("MaxItemSize must be a positive value")
+	}
+	b.server.maxItemSize = size
+	return b
+}
+
+// Build finalizes configuration and returns the Server for use.
+func (b *builder[S]) Build() (server *Server[S]) {
+	server = b.server
+	b.server = nil
+	server.started = time.Now()
+	return
+}
+
+// Server serves the memcached text protocol, get, gets, set, add, replace, append, prepend, cas, delete, incr, decr,
+// touch, stats, flush_all, version and quit, on top of a cache.
+type Server[S any] struct {
+	cache       Cache[S]
+	maxItemSize int
+	started     time.Time
+
+	// mu serializes all writes so compound commands, such as add, cas and incr, are atomic.
+	mu  sync.Mutex
+	cas atomic.Uint64
+
+	stats            stats.Totals[S]
+	currConnections  atomic.Int64
+	totalConnections atomic.Uint64
+	flushes          atomic.Uint64
+
+	srv netserver.Server
+}
+
+// ListenAndServe listens on the network, tcp or unix, and address then calls Serve.
+func (s *Server[S]) ListenAndServe(network, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener, serving each on its own goroutine, until the Server is closed.
+func (s *Server[S]) Serve(l net.Listener) error {
+	return s.srv.Serve(l, s.serve)
+}
+
+// Close stops all listeners, closes all connections and waits for them to finish.
+func (s *Server[S]) Close() error {
+	return s.srv.Close()
+}
+
+// statNames maps cache Stats fields to their memcached names. Fields not listed are reported in snake case and
+// fields mapped to an empty name are omitted.
+var statNames = map[string]string{
+	"Capacity":          "limit_maxbytes",
+	"Len":               "curr_items",
+	"Weight":            "bytes",
+	"Hits":              "get_hits",
+	"Misses":            "get_misses",
+	"Gets":              "cmd_get",
+	"Sets":              "cmd_set",
+	"CapacityEvictions": "evictions",
+	"Expirations":       "reclaimed",
+	"Evictions":         "",
+}
+
+// rejected accumulates the caches Stats delta into the running totals, reporting if the cache rejected the item just
+// set for weighing more than its capacity. It must be called with the write lock held.
+func (s *Server[S]) rejected() bool {
+	delta := s.cache.Stats()
+	s.stats.Sum(delta)
+	return stats.Counter(delta, "Rejections") > 0
+}
+
+// cacheStats accumulates the caches Stats delta into the running totals and returns them as name value pairs.
+func (s *Server[S]) cacheStats() (fields [][2]string) {
+	s.mu.Lock()
+	totals := s.stats.Add(s.cache.Stats())
+	s.mu.Unlock()
+	for _, f := range totals {
+		name, found := statNames[f.Name]
+		if !found {
+			name = stats.SnakeCase(f.Name)
+		}
+		if name != "" {
+			fields = append(fields, [2]string{name, f.Value})
+		}
+	}
+	return
+}
@@ -0,0 +1,204 @@
+package memcached
+
+import (
+	"bufio"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func start[S any](t *testing.T, cache Cache[S]) (*Server[S], *client) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Equal(t, err, nil)
+	server := New(cache).MaxItemSize(16).Build()
+	go func() { _ = server.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	Equal(t, err, nil)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = server.Close()
+	})
+	return server, &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends the command and reads lines until one of the terminators, returning them joined by |.
+func (c *client) do(cmd string, terminators ...string) string {
+	_, err := c.conn.Write([]byte(cmd + "\r\n"))
+	Equal(c.t, err, nil)
+	var lines []string
+	for {
+		line, err := c.r.ReadString('\n')
+		Equal(c.t, err, nil)
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		if len(terminators) == 0 {
+			return line
+		}
+		for _, term := range terminators {
+			if strings.HasPrefix(line, term) {
+				return strings.Join(lines, "|")
+			}
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	cache := lru.New[string, *Item](1 << 10).Weigher(Weigh).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	Equal(t, c.do("set a 5 0 3\r\nabc"), "STORED")
+	Equal(t, c.do("get a b", "END"), "VALUE a 5 3|abc|END")
+	Equal(t, c.do("add a 0 0 1\r\nx"), "NOT_STORED")
+	Equal(t, c.do("add b 0 0 1\r\nx"), "STORED")
+	Equal(t, c.do("replace c 0 0 1\r\nx"), "NOT_STORED")
+	Equal(t, c.do("replace b 1 0 1\r\ny"), "STORED")
+	Equal(t, c.do("append b 0 0 1\r\nz"), "STORED")
+	Equal(t, c.do("prepend b 0 0 1\r\nx"), "STORED")
+	Equal(t, c.do("get b", "END"), "VALUE b 1 3|xyz|END")
+
+	// cas
+	line := c.do("gets a", "END")
+	fields := strings.Fields(strings.Split(line, "|")[0])
+	Equal(t, len(fields), 5)
+	unique := fields[4]
+	Equal(t, c.do("cas a 0 0 1 "+unique+"\r\nd"), "STORED")
+	Equal(t, c.do("cas a 0 0 1 "+unique+"\r\ne"), "EXISTS")
+	Equal(t, c.do("cas z 0 0 1 1\r\ne"), "NOT_FOUND")
+	Equal(t, c.do("get a", "END"), "VALUE a 0 1|d|END")
+
+	// incr/decr
+	Equal(t, c.do("set n 0 0 2\r\n10"), "STORED")
+	Equal(t, c.do("incr n 5"), "15")
+	Equal(t, c.do("decr n 100"), "0")
+	Equal(t, c.do("incr a 1"), msgNonNumeric)
+	Equal(t, c.do("incr z 1"), "NOT_FOUND")
+	Equal(t, c.do("incr n x"), msgInvalidDelta)
+
+	// delete, noreply & touch
+	Equal(t, c.do("delete a"), "DELETED")
+	Equal(t, c.do("delete a"), "NOT_FOUND")
+	Equal(t, c.do("set q 0 0 1 noreply\r\nq\r\nget q", "END"), "VALUE q 0 1|q|END")
+	Equal(t, c.do("touch q 100"), "TOUCHED")
+	Equal(t, c.do("touch a 100"), "NOT_FOUND")
+
+	// errors
+	Equal(t, c.do("bogus"), "ERROR")
+	Equal(t, c.do("set a 0 0"), msgBadFormat)
+	Equal(t, c.do("set a 0 0 1\r\nabc"), msgBadChunk)
+	Equal(t, c.do("set a 0 0 17\r\n01234567890123456"), msgTooLarge)
+	Equal(t, c.do("set a 0 0 17 noreply\r\n01234567890123456\r\nversion"), "VERSION "+Version)
+	Equal(t, c.do("get "+strings.Repeat("a", 251)), msgBadFormat)
+	Equal(t, c.do("version"), "VERSION "+Version)
+
+	stats := c.do("stats", "END")
+	Equal(t, strings.Contains(stats, "STAT curr_items 3|"), true)
+	Equal(t, strings.Contains(stats, "STAT limit_maxbytes 1024|"), true)
+	Equal(t, strings.Contains(stats, "STAT cmd_set "), true)
+	Equal(t, strings.Contains(stats, "STAT negative_hits 0|"), true)
+	Equal(t, strings.Contains(stats, "STAT curr_connections 1|"), true)
+	Equal(t, strings.Contains(stats, "STAT evictions 0|"), true)
+
+	Equal(t, c.do("flush_all"), "OK")
+	Equal(t, c.do("get b n q", "END"), "END")
+	stats = c.do("stats", "END")
+	Equal(t, strings.Contains(stats, "STAT curr_items 0|"), true)
+	Equal(t, strings.Contains(stats, "STAT cmd_flush 1|"), true)
+}
+
+func TestServerTooLarge(t *testing.T) {
+	cache := lru.New[string, *Item](1 << 10).Weigher(Weigh).BuildThreadSafe()
+	_, c := start(t, cache)
+	addr := c.conn.RemoteAddr().String()
+
+	// replied to before the data arrives, the connection being closed when it can never be swallowed.
+	Equal(t, c.do("set k 0 0 9223372036854775807"), msgTooLarge)
+	_, err := c.r.ReadString('\n')
+	Equal(t, err, io.EOF)
+
+	// the server remains up.
+	conn, err := net.Dial("tcp", addr)
+	Equal(t, err, nil)
+	defer conn.Close()
+	c = &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+	Equal(t, c.do("set a 0 0 1\r\na"), "STORED")
+}
+
+func TestServerExptime(t *testing.T) {
+	cache := lfu.New[string, *Item](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	Equal(t, c.do("set a 0 -1 1\r\na"), "STORED")
+	Equal(t, c.do("get a", "END"), "END")
+
+	Equal(t, c.do("set a 0 1 1\r\na"), "STORED")
+	Equal(t, c.do("get a", "END"), "VALUE a 0 1|a|END")
+	time.Sleep(1100 * time.Millisecond)
+	Equal(t, c.do("get a", "END"), "END")
+
+	d, expires, ok := maxAge(time.Now().Add(time.Hour).Unix())
+	Equal(t, ok, true)
+	Equal(t, d > 59*time.Minute, true)
+	Equal(t, expires.IsZero(), false)
+}
+
+func TestServerStatsAccumulate(t *testing.T) {
+	cache := lru.New[string, *Item](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	Equal(t, c.do("get a", "END"), "END")
+	Equal(t, strings.Contains(c.do("stats", "END"), "STAT get_misses 1|"), true)
+	Equal(t, c.do("get a", "END"), "END")
+	Equal(t, strings.Contains(c.do("stats", "END"), "STAT get_misses 2|"), true)
+}
+
+func TestServerRejected(t *testing.T) {
+	cache := lru.New[string, *Item](8).Weigher(Weigh).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	// items weighing more than the capacity are rejected by the cache.
+	Equal(t, c.do("set a 0 0 8\r\n01234567"), msgOutOfMemory)
+	Equal(t, c.do("get a", "END"), "END")
+	Equal(t, c.do("set a 0 0 4\r\n0123"), "STORED")
+	Equal(t, c.do("append a 0 0 4\r\n4567"), msgOutOfMemory)
+	Equal(t, c.do("get a", "END"), "END")
+	Equal(t, c.do("set a 0 0 7\r\n0123456"), "STORED")
+	Equal(t, strings.Contains(c.do("stats", "END"), "STAT rejections 2|"), true)
+}
+
+func TestServerFlushStats(t *testing.T) {
+	cache := lru.New[string, *Item](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	// counts since the last stats command survive the flush resetting the caches Stats.
+	Equal(t, c.do("set a 0 0 1\r\na"), "STORED")
+	Equal(t, c.do("get a", "END"), "VALUE a 0 1|a|END")
+	Equal(t, c.do("flush_all"), "OK")
+	stats := c.do("stats", "END")
+	Equal(t, strings.Contains(stats, "STAT cmd_set 1|"), true)
+	Equal(t, strings.Contains(stats, "STAT get_hits 1|"), true)
+	Equal(t, strings.Contains(stats, "STAT curr_items 0|"), true)
+}
+
+func TestServerClose(t *testing.T) {
+	cache := lru.New[string, *Item](10).BuildThreadSafe()
+	server, c := start(t, cache)
+	Equal(t, c.do("set a 0 0 1\r\na"), "STORED")
+	Equal(t, server.Close(), nil)
+	_, err := c.r.ReadString('\n')
+	NotEqual(t, err, nil)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Equal(t, err, nil)
+	Equal(t, server.Serve(l), ErrServerClosed)
+}
@@ -0,0 +1,353 @@
+package mrc
+
+import (
+	"container/heap"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/maphash"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Cache is the set of functions an Estimator wraps, satisfied by the lru and lfu Cache and ThreadSafeCache along
+// with lru.ConcurrentCache and lfu.ShardedCache.
+type Cache[K comparable, V any] interface {
+	Set(key K, value V)
+	Get(key K) optionext.Option[V]
+	Remove(key K)
+}
+
+// Point is the estimated hit ratio at a capacity.
+type Point struct {
+	// Capacity is the number of entries.
+	Capacity int
+
+	// HitRatio is the estimated fraction of Gets which would hit, between 0 and 1.
+	HitRatio float64
+}
+
+// DefaultFactors are the multiples of the current capacity estimated by Curve when none are provided.
+var DefaultFactors = []float64{0.5, 0.75, 1, 1.5, 2, 3, 4}
+
+type builder[K comparable, V any] struct {
+	estimator *Estimator[K, V]
+}
+
+// New initializes a builder to create an Estimator wrapping the cache.
+func New[K comparable, V any](cache Cache[K, V]) *builder[K, V] {
+	return &builder[K, V]{
+		estimator: &Estimator[K, V]{
+			cache: cache,
+			seed:  maphash.MakeSeed(),
+			rate:  0.01,
+		},
+	}
+}
+
+// Rate sets the fraction of keys, between 0 and 1, sampled by hash to track reuse distances. Lower rates use less
+// memory and CPU at the cost of accuracy, particularly at small capacities.
+//
+// Default is 0.01.
+func (b *builder[K, V]) Rate(rate float64) *builder[K, V] {
+	if rate <= 0 || rate > 1 {
+		panic("Rate must be greater than 0 and at most 1")
+	}
+	b.estimator.rate = rate
+	return b
+}
+
+// MaxKeys bounds the number of sampled keys tracked, lowering the sampling rate as required to remain within it, so
+// memory is bounded regardless of the number of distinct keys.
+//
+// Default is unbounded, memory growing with the number of distinct keys multiplied by the Rate.
+func (b *builder[K, V]) MaxKeys(n int) *builder[K, V] {
+	if n <= 0 {
+		panic("MaxKeys must be a positive value")
+	}
+	b.estimator.maxKeys = n
+	return b
+}
+
+// Build finalizes configuration and returns the Estimator for use.
+func (b *builder[K, V]) Build() (estimator *Estimator[K, V]) {
+	estimator = b.estimator
+	b.estimator = nil
+	estimator.initial = estimator.rate
+	estimator.Reset()
+	return
+}
+
+// Estimator wraps a cache estimating the hit ratio it would achieve at other capacities, its miss ratio curve, from
+// the live stream of Gets using spatially hashed sampling (SHARDS) of reuse distances.
+//
+// Reuse distance, the number of distinct keys accessed between two accesses of the same key, determines exactly
+// whether an LRU cache of a given capacity would hit, and so the curve models LRU. It remains a useful guide for LFU
+// caches, which usually perform at least as well on skewed workloads.
+//
+// Only sampled keys take a lock, so overhead on the remaining Gets is a hash and a comparison.
+type Estimator[K comparable, V any] struct {
+	cache   Cache[K, V]
+	seed    maphash.Seed
+	initial float64
+	maxKeys int
+
+	// threshold is the sampling threshold, keys hashing at or below it are sampled.
+	threshold atomic.Uint64
+	// gets is the total number of Gets, sampled or not.
+	gets atomic.Uint64
+
+	mu      sync.Mutex
+	rate    float64
+	keys    map[K]*tracked[K]
+	byHash  hashHeap[K]
+	tree    fenwick
+	clock   int
+	hist    []float64
+	sampled float64
+}
+
+type tracked[K comparable] struct {
+	key   K
+	hash  uint64
+	last  int
+	index int
+}
+
+// Set sets the item into the cache.
+func (e *Estimator[K, V]) Set(key K, value V) {
+	e.cache.Set(key, value)
+}
+
+// Get gets the item from the cache, recording the access.
+func (e *Estimator[K, V]) Get(key K) optionext.Option[V] {
+	e.access(key)
+	return e.cache.Get(key)
+}
+
+// Remove removes the item from the cache, its next access then being treated as a first access.
+func (e *Estimator[K, V]) Remove(key K) {
+	e.cache.Remove(key)
+	h := maphash.Comparable(e.seed, key)
+	if h > e.threshold.Load() {
+		return
+	}
+	e.mu.Lock()
+	if t, found := e.keys[key]; found {
+		e.forget(t)
+	}
+	e.mu.Unlock()
+}
+
+// Cache returns the wrapped cache for access to functions which are not wrapped.
+func (e *Estimator[K, V]) Cache() Cache[K, V] {
+	return e.cache
+}
+
+func (e *Estimator[K, V]) access(key K) {
+	e.gets.Add(1)
+	h := maphash.Comparable(e.seed, key)
+	if h > e.threshold.Load() {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if h > e.threshold.Load() {
+		return
+	}
+
+	if e.clock == e.tree.len() {
+		e.compact()
+	}
+	now := e.clock
+	e.clock++
+	e.sampled++
+
+	t, found := e.keys[key]
+	if found {
+		// distinct keys accessed since the last access of this key
+		distance := e.tree.sum(now-1) - e.tree.sum(t.last)
+		e.tree.add(t.last, -1)
+		t.last = now
+		e.tree.add(now, 1)
+		e.record(float64(distance) / e.rate)
+		return
+	}
+
+	t = &tracked[K]{key: key, hash: h, last: now}
+	e.keys[key] = t
+	e.tree.add(now, 1)
+	if e.maxKeys > 0 {
+		heap.Push(&e.byHash, t)
+		if len(e.keys) > e.maxKeys {
+			e.lower()
+		}
+	}
+}
+
+// record adds a scaled reuse distance to the histogram whose buckets are 1/initial rate wide.
+func (e *Estimator[K, V]) record(distance float64) {
+	bucket := int(distance * e.initial)
+	if bucket >= len(e.hist) {
+		e.hist = append(e.hist, make([]float64, bucket-len(e.hist)+1)...)
+	}
+	e.hist[bucket]++
+}
+
+// lower evicts the tracked key with the largest hash, lowering the sampling threshold to its hash and rescaling the
+// histogram to the new rate.
+func (e *Estimator[K, V]) lower() {
+	t := heap.Pop(&e.byHash).(*tracked[K])
+	e.tree.add(t.last, -1)
+	delete(e.keys, t.key)
+
+	threshold := t.hash - 1
+	rate := float64(threshold) / math.MaxUint64
+	scale := rate / e.rate
+	for i := range e.hist {
+		e.hist[i] *= scale
+	}
+	e.sampled *= scale
+	e.rate = rate
+	e.threshold.Store(threshold)
+}
+
+// forget stops tracking the key.
+func (e *Estimator[K, V]) forget(t *tracked[K]) {
+	e.tree.add(t.last, -1)
+	delete(e.keys, t.key)
+	if e.maxKeys > 0 {
+		heap.Remove(&e.byHash, t.index)
+	}
+}
+
+// compact renumbers the last access times of the tracked keys from zero, growing the tree if more than half full.
+func (e *Estimator[K, V]) compact() {
+	order := make([]*tracked[K], e.tree.len())
+	for _, t := range e.keys {
+		order[t.last] = t
+	}
+	size := e.tree.len()
+	if len(e.keys) > size/2 {
+		size *= 2
+	}
+	e.tree = newFenwick(size)
+	e.clock = 0
+	for _, t := range order {
+		if t != nil {
+			t.last = e.clock
+			e.tree.add(e.clock, 1)
+			e.clock++
+		}
+	}
+}
+
+// HitRatio returns the estimated hit ratio of an LRU cache with the capacity, between 0 and 1.
+func (e *Estimator[K, V]) HitRatio(capacity int) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	expected := float64(e.gets.Load()) * e.rate
+	if expected == 0 {
+		return 0
+	}
+	width := 1 / e.initial
+	var hits float64
+	for bucket, count := range e.hist {
+		low := float64(bucket) * width
+		if low >= float64(capacity) {
+			break
+		}
+		if high := low + width; high > float64(capacity) {
+			count *= (float64(capacity) - low) / width
+		}
+		hits += count
+	}
+	// SHARDS adjustment, attributing the difference between the expected and actual number of sampled accesses to
+	// the smallest distances.
+	hits += expected - e.sampled
+	return min(max(hits/expected, 0), 1)
+}
+
+// Curve returns the estimated hit ratios at multiples of the provided capacity, DefaultFactors if none provided.
+func (e *Estimator[K, V]) Curve(capacity int, factors ...float64) []Point {
+	if len(factors) == 0 {
+		factors = DefaultFactors
+	}
+	points := make([]Point, len(factors))
+	for i, f := range factors {
+		c := int(math.Round(float64(capacity) * f))
+		points[i] = Point{Capacity: c, HitRatio: e.HitRatio(c)}
+	}
+	return points
+}
+
+// Reset discards all tracked accesses, starting the estimation afresh, for example after a change in workload.
+func (e *Estimator[K, V]) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gets.Store(0)
+	e.rate = e.initial
+	e.threshold.Store(threshold(e.rate))
+	e.keys = make(map[K]*tracked[K])
+	e.byHash = nil
+	e.tree = newFenwick(1024)
+	e.clock = 0
+	e.hist = nil
+	e.sampled = 0
+}
+
+func threshold(rate float64) uint64 {
+	if rate >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(rate * math.MaxUint64)
+}
+
+// hashHeap is a max heap of tracked keys by hash.
+type hashHeap[K comparable] []*tracked[K]
+
+func (h hashHeap[K]) Len() int           { return len(h) }
+func (h hashHeap[K]) Less(i, j int) bool { return h[i].hash > h[j].hash }
+func (h hashHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *hashHeap[K]) Push(x any) {
+	t := x.(*tracked[K])
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+func (h *hashHeap[K]) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return t
+}
+
+// fenwick is a binary indexed tree counting the tracked keys by their last access time.
+type fenwick []int
+
+func newFenwick(size int) fenwick {
+	return make(fenwick, size+1)
+}
+
+func (f fenwick) len() int {
+	return len(f) - 1
+}
+
+func (f fenwick) add(i, delta int) {
+	for i++; i < len(f); i += i & -i {
+		f[i] += delta
+	}
+}
+
+// sum returns the count of positions 0 through i inclusive.
+func (f fenwick) sum(i int) (total int) {
+	for i++; i > 0; i -= i & -i {
+		total += f[i]
+	}
+	return
+}
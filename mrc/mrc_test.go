@@ -0,0 +1,132 @@
+package mrc
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// zipf returns a skewed trace of n accesses over keys distinct keys.
+func zipf(n int, keys uint64) []uint64 {
+	z := rand.NewZipf(rand.New(rand.NewPCG(1, 2)), 1.01, 10, keys-1)
+	trace := make([]uint64, n)
+	for i := range trace {
+		trace[i] = z.Uint64()
+	}
+	return trace
+}
+
+// actual returns the hit ratio of an LRU cache with the capacity replaying the trace.
+func actual(capacity int, trace []uint64) float64 {
+	cache := lru.New[uint64, struct{}](capacity).Build()
+	var hits int
+	for _, key := range trace {
+		if cache.Get(key).IsSome() {
+			hits++
+		} else {
+			cache.Set(key, struct{}{})
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func replay(e *Estimator[uint64, struct{}], trace []uint64) {
+	for _, key := range trace {
+		if e.Get(key).IsNone() {
+			e.Set(key, struct{}{})
+		}
+	}
+}
+
+func TestEstimatorExact(t *testing.T) {
+	trace := zipf(50_000, 5_000)
+	e := New[uint64, struct{}](lru.New[uint64, struct{}](100).Build()).Rate(1).Build()
+	replay(e, trace)
+
+	// sampling every key gives the exact LRU hit ratio
+	for _, c := range []int{1, 10, 100, 1000, 5000} {
+		Equal(t, math.Abs(e.HitRatio(c)-actual(c, trace)) < 1e-9, true)
+	}
+	Equal(t, e.HitRatio(0), 0.0)
+
+	points := e.Curve(100)
+	Equal(t, len(points), len(DefaultFactors))
+	Equal(t, points[0].Capacity, 50)
+	Equal(t, points[len(points)-1].Capacity, 400)
+	for i := 1; i < len(points); i++ {
+		Equal(t, points[i].HitRatio >= points[i-1].HitRatio, true)
+	}
+
+	e.Reset()
+	Equal(t, e.HitRatio(100), 0.0)
+}
+
+// estimated returns the hit ratios averaged across estimators, each sampling different keys, to reduce variance.
+func estimated(trace []uint64, capacities []int, build func() *Estimator[uint64, struct{}]) []float64 {
+	const runs = 4
+	ratios := make([]float64, len(capacities))
+	for i := 0; i < runs; i++ {
+		e := build()
+		replay(e, trace)
+		for j, c := range capacities {
+			ratios[j] += e.HitRatio(c) / runs
+		}
+	}
+	return ratios
+}
+
+func meanAbsoluteError(trace []uint64, capacities []int, ratios []float64) (mae float64) {
+	for i, c := range capacities {
+		mae += math.Abs(ratios[i]-actual(c, trace)) / float64(len(capacities))
+	}
+	return
+}
+
+func TestEstimatorSampled(t *testing.T) {
+	trace := zipf(200_000, 50_000)
+	capacities := []int{500, 1000, 4000, 20_000}
+	ratios := estimated(trace, capacities, func() *Estimator[uint64, struct{}] {
+		return New[uint64, struct{}](lfu.New[uint64, struct{}](1000).Build()).Rate(0.05).Build()
+	})
+	Equal(t, meanAbsoluteError(trace, capacities, ratios) < 0.04, true)
+}
+
+func TestEstimatorMaxKeys(t *testing.T) {
+	trace := zipf(200_000, 50_000)
+	capacities := []int{1000, 4000, 20_000}
+	ratios := estimated(trace, capacities, func() *Estimator[uint64, struct{}] {
+		return New[uint64, struct{}](lru.New[uint64, struct{}](1000).BuildThreadSafe()).Rate(1).MaxKeys(2048).Build()
+	})
+	Equal(t, meanAbsoluteError(trace, capacities, ratios) < 0.04, true)
+
+	e := New[uint64, struct{}](lru.New[uint64, struct{}](1000).Build()).MaxKeys(100).Build()
+	replay(e, trace)
+	Equal(t, len(e.keys) <= 100, true)
+	Equal(t, e.rate < 0.01, true)
+}
+
+func TestEstimatorRemove(t *testing.T) {
+	e := New[int, int](lru.New[int, int](10).Build()).Rate(1).MaxKeys(10).Build()
+	e.Set(1, 1)
+	e.Get(1)
+	e.Get(1)
+	Equal(t, e.HitRatio(1), 0.5)
+	e.Remove(1)
+	e.Get(1)
+	Equal(t, e.HitRatio(1), 1/3.0)
+	Equal(t, len(e.byHash), 1)
+}
+
+func TestFenwickCompact(t *testing.T) {
+	e := New[int, int](lru.New[int, int](10).Build()).Rate(1).Build()
+	// cycling over 3 keys for more accesses than the tree holds forces compaction
+	for i := 0; i < 5000; i++ {
+		e.Get(i % 3)
+	}
+	Equal(t, e.tree.len(), 1024)
+	Equal(t, e.HitRatio(3), 4997/5000.0)
+	Equal(t, e.HitRatio(2), 0.0)
+}
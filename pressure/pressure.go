@@ -0,0 +1,225 @@
+package pressure
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+)
+
+// Sample is a point in time reading of the heap.
+type Sample struct {
+	// Goal is the heap size, in bytes, the GC is trying to stay within. It is influenced by GOMEMLIMIT and GOGC.
+	Goal uint64
+
+	// Live is the heap memory, in bytes, occupied by live objects as of the last GC.
+	Live uint64
+}
+
+// Source provides heap samples to the Controller.
+//
+// It allows the Controller to be driven by a fake in tests.
+type Source interface {
+	Sample() Sample
+}
+
+// SourceFunc is an adapter to allow the use of an ordinary function as a Source.
+type SourceFunc func() Sample
+
+// Sample calls f().
+func (f SourceFunc) Sample() Sample {
+	return f()
+}
+
+type runtimeSource struct {
+	samples []metrics.Sample
+}
+
+// Runtime returns a Source backed by runtime/metrics.
+//
+// The returned Source is not safe for concurrent use; each Controller should be given its own.
+func Runtime() Source {
+	return &runtimeSource{
+		samples: []metrics.Sample{
+			{Name: "/gc/heap/goal:bytes"},
+			{Name: "/gc/heap/live:bytes"},
+		},
+	}
+}
+
+func (r *runtimeSource) Sample() (sample Sample) {
+	metrics.Read(r.samples)
+	if r.samples[0].Value.Kind() == metrics.KindUint64 {
+		sample.Goal = r.samples[0].Value.Uint64()
+	}
+	if r.samples[1].Value.Kind() == metrics.KindUint64 {
+		sample.Live = r.samples[1].Value.Uint64()
+	}
+	return
+}
+
+// Resizer is a cache whose capacity can be changed at runtime such as lru.ThreadSafeCache and lfu.ThreadSafeCache.
+type Resizer interface {
+	Capacity() int
+	Resize(capacity int)
+}
+
+type registration struct {
+	cache    Resizer
+	min, max int
+	start    int
+	capacity int
+}
+
+type builder struct {
+	controller *Controller
+}
+
+// New initializes a builder to create a Controller reading heap samples from the provided Source.
+func New(source Source) *builder {
+	return &builder{
+		controller: &Controller{
+			source:   source,
+			interval: time.Second,
+			low:      0.7,
+			high:     0.9,
+			shrink:   0.25,
+			grow:     0.1,
+		},
+	}
+}
+
+// Interval sets how often the Controller samples the heap when started with Run.
+//
+// Default is one second.
+func (b *builder) Interval(interval time.Duration) *builder {
+	if interval <= 0 {
+		panic("Interval must be a positive value")
+	}
+	b.controller.interval = interval
+	return b
+}
+
+// Thresholds sets the live heap to heap goal ratios between which the Controller holds capacity steady. At or above
+// high, caches are shrunk and at or below low, caches are grown back towards the capacity they were registered with.
+//
+// Default is 0.7 and 0.9.
+func (b *builder) Thresholds(low, high float64) *builder {
+	if low <= 0 || high <= low {
+		panic("Thresholds must satisfy 0 < low < high")
+	}
+	b.controller.low = low
+	b.controller.high = high
+	return b
+}
+
+// Steps sets the fraction of the scale removed on each shrink and added on each grow.
+//
+// Default is 0.25 and 0.1, shrinking quickly and growing back slowly.
+func (b *builder) Steps(shrink, grow float64) *builder {
+	if shrink <= 0 || shrink > 1 || grow <= 0 || grow > 1 {
+		panic("Steps must be within (0, 1]")
+	}
+	b.controller.shrink = shrink
+	b.controller.grow = grow
+	return b
+}
+
+// Register adds a cache to be resized by the Controller within the min and max capacities.
+func (b *builder) Register(cache Resizer, min, max int) *builder {
+	if min < 0 || max < min {
+		panic("Register must satisfy 0 <= min <= max")
+	}
+	b.controller.caches = append(b.controller.caches, &registration{
+		cache: cache,
+		min:   min,
+		max:   max,
+	})
+	return b
+}
+
+// Build finalizes configuration and returns the Controller for use.
+//
+// Registered caches keep their current capacity, clamped within their min and max, which is the most they are grown
+// back to.
+func (b *builder) Build() (controller *Controller) {
+	controller = b.controller
+	b.controller = nil
+	controller.scale.Store(math.Float64bits(1))
+	for _, r := range controller.caches {
+		r.capacity = r.cache.Capacity()
+		if clamped := min(max(r.capacity, r.min), r.max); clamped != r.capacity {
+			r.capacity = clamped
+			r.cache.Resize(clamped)
+		}
+		r.start = r.capacity
+	}
+	return
+}
+
+// Controller shrinks registered caches proportionally when the live heap approaches the heap goal and grows them
+// back once the pressure subsides.
+//
+// Each cache's capacity is computed as min + (max - min) * scale, where scale is shared across all registered caches,
+// capped at the capacity it was registered with.
+type Controller struct {
+	source   Source
+	caches   []*registration
+	interval time.Duration
+	low      float64
+	high     float64
+	shrink   float64
+	grow     float64
+	scale    atomic.Uint64
+}
+
+// Run samples the heap every interval adjusting caches until the context is cancelled.
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Adjust()
+		}
+	}
+}
+
+// Adjust takes a single heap sample and resizes the registered caches if required.
+//
+// It is called by Run and is exposed for driving the Controller manually. It is not safe to call concurrently.
+func (c *Controller) Adjust() {
+	sample := c.source.Sample()
+	if sample.Goal == 0 {
+		return
+	}
+	ratio := float64(sample.Live) / float64(sample.Goal)
+	scale := c.Scale()
+
+	switch {
+	case ratio >= c.high:
+		scale -= scale * c.shrink
+	case ratio <= c.low:
+		scale = math.Min(1, scale+c.grow)
+	default:
+		return
+	}
+	c.scale.Store(math.Float64bits(scale))
+
+	for _, r := range c.caches {
+		capacity := min(r.min+int(float64(r.max-r.min)*scale), r.start)
+		if capacity != r.capacity {
+			r.capacity = capacity
+			r.cache.Resize(capacity)
+		}
+	}
+}
+
+// Scale returns the current scale, between 0 and 1, applied to all registered caches.
+func (c *Controller) Scale() float64 {
+	return math.Float64frombits(c.scale.Load())
+}
@@ -0,0 +1,143 @@
+package pressure
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"testing"
+)
+
+func TestControllerBadConfig(t *testing.T) {
+	PanicMatches(t, func() {
+		New(Runtime()).Thresholds(0.9, 0.7)
+	}, "Thresholds must satisfy 0 < low < high")
+	PanicMatches(t, func() {
+		New(Runtime()).Steps(0, 0.1)
+	}, "Steps must be within (0, 1]")
+	PanicMatches(t, func() {
+		New(Runtime()).Interval(0)
+	}, "Interval must be a positive value")
+	PanicMatches(t, func() {
+		New(Runtime()).Register(lru.New[string, int](10).BuildThreadSafe(), 10, 5)
+	}, "Register must satisfy 0 <= min <= max")
+}
+
+func TestControllerShrinkAndGrow(t *testing.T) {
+	var sample Sample
+	source := SourceFunc(func() Sample { return sample })
+
+	c1 := lru.New[int, int](110).BuildThreadSafe()
+	c2 := lfu.New[int, int](20).BuildThreadSafe()
+	controller := New(source).
+		Thresholds(0.5, 0.9).
+		Steps(0.5, 0.25).
+		Register(c1, 10, 110).
+		Register(c2, 0, 20).
+		Build()
+	Equal(t, c1.Stats().Capacity, 110)
+	Equal(t, c2.Stats().Capacity, 20)
+
+	for i := 0; i < 20; i++ {
+		c1.Set(i, i)
+		c2.Set(i, i)
+	}
+
+	// no sample yet, nothing to do
+	controller.Adjust()
+	Equal(t, controller.Scale(), 1.0)
+
+	// within thresholds, steady
+	sample = Sample{Goal: 100, Live: 70}
+	controller.Adjust()
+	Equal(t, controller.Scale(), 1.0)
+
+	// under pressure
+	sample = Sample{Goal: 100, Live: 95}
+	controller.Adjust()
+	Equal(t, controller.Scale(), 0.5)
+	stats := c1.Stats()
+	Equal(t, stats.Capacity, 60)
+	Equal(t, stats.Len, 20)
+	stats2 := c2.Stats()
+	Equal(t, stats2.Capacity, 10)
+	Equal(t, stats2.Len, 10)
+	Equal(t, stats2.Evictions, uint(10))
+
+	controller.Adjust()
+	Equal(t, controller.Scale(), 0.25)
+	Equal(t, c1.Stats().Capacity, 35)
+	Equal(t, c2.Stats().Capacity, 5)
+
+	// pressure subsided
+	sample = Sample{Goal: 100, Live: 40}
+	controller.Adjust()
+	Equal(t, controller.Scale(), 0.5)
+	Equal(t, c1.Stats().Capacity, 60)
+	Equal(t, c2.Stats().Capacity, 10)
+
+	for i := 0; i < 5; i++ {
+		controller.Adjust()
+	}
+	Equal(t, controller.Scale(), 1.0)
+	Equal(t, c1.Stats().Capacity, 110)
+	Equal(t, c2.Stats().Capacity, 20)
+}
+
+func TestControllerCurrentCapacity(t *testing.T) {
+	var sample Sample
+	source := SourceFunc(func() Sample { return sample })
+
+	c1 := lru.New[int, int](50).BuildConcurrent()
+	c2 := lfu.New[int, int](5).BuildSharded(2)
+	c3 := lru.New[int, int](500).BuildThreadSafe()
+	controller := New(source).
+		Steps(0.5, 0.5).
+		Register(c1, 10, 110).
+		Register(c2, 10, 110).
+		Register(c3, 10, 110).
+		Build()
+	Equal(t, c1.Capacity(), 50)
+	Equal(t, c2.Capacity(), 10)
+	Equal(t, c3.Capacity(), 110)
+
+	// shrinking leaves caches already below their scaled capacity untouched.
+	sample = Sample{Goal: 100, Live: 95}
+	controller.Adjust()
+	Equal(t, controller.Scale(), 0.5)
+	Equal(t, c1.Capacity(), 50)
+	Equal(t, c2.Capacity(), 10)
+	Equal(t, c3.Capacity(), 60)
+
+	controller.Adjust()
+	Equal(t, c1.Capacity(), 35)
+
+	// growing back to the registered capacity.
+	sample = Sample{Goal: 100, Live: 10}
+	for i := 0; i < 3; i++ {
+		controller.Adjust()
+	}
+	Equal(t, controller.Scale(), 1.0)
+	Equal(t, c1.Capacity(), 50)
+	Equal(t, c2.Capacity(), 10)
+	Equal(t, c3.Capacity(), 110)
+}
+
+func TestControllerLowPressureAfterBuild(t *testing.T) {
+	c1 := lru.New[int, int](50).BuildThreadSafe()
+	c2 := lfu.New[int, int](20).BuildThreadSafe()
+	controller := New(SourceFunc(func() Sample { return Sample{Goal: 100, Live: 10} })).
+		Register(c1, 10, 110).
+		Register(c2, 0, 20).
+		Build()
+
+	// caches which were never shrunk are not grown.
+	controller.Adjust()
+	Equal(t, controller.Scale(), 1.0)
+	Equal(t, c1.Capacity(), 50)
+	Equal(t, c2.Capacity(), 20)
+}
+
+func TestRuntimeSource(t *testing.T) {
+	sample := Runtime().Sample()
+	NotEqual(t, sample.Goal, uint64(0))
+}
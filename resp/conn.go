@@ -0,0 +1,444 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxArgs       = 1 << 20
+	maxInlineSize = 64 << 10
+	// bulkChunkSize is the most read into a bulk string at once, its buffer growing as data arrives rather than
+	// trusting the length sent by the client up front.
+	bulkChunkSize = 64 << 10
+)
+
+var (
+	crlf           = []byte("\r\n")
+	msgSyntax      = "ERR syntax error"
+	msgNotInteger  = "ERR value is not an integer or out of range"
+	msgInvalidExp  = "ERR invalid expire time in 'set' command"
+	msgValueTooBig = "ERR string exceeds maximum allowed size"
+	msgOOM         = "OOM command not allowed when used memory > 'maxmemory'."
+)
+
+// protocolError is a malformed request after which the connection is closed.
+type protocolError string
+
+func (e protocolError) Error() string {
+	return string(e)
+}
+
+// conn is a single client connection.
+type conn[S any] struct {
+	s *Server[S]
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (s *Server[S]) serve(nc net.Conn) {
+	s.connectedClients.Add(1)
+	s.totalConnections.Add(1)
+	defer s.connectedClients.Add(-1)
+
+	c := &conn[S]{
+		s: s,
+		r: bufio.NewReaderSize(nc, maxInlineSize),
+		w: bufio.NewWriter(nc),
+	}
+	for {
+		args, err := c.readCommand()
+		if err != nil {
+			var pe protocolError
+			if errors.As(err, &pe) {
+				c.error("ERR Protocol error: " + pe.Error())
+				_ = c.w.Flush()
+			}
+			return
+		}
+		if len(args) > 0 {
+			s.commands.Add(1)
+			if !c.handle(strings.ToUpper(string(args[0])), args[1:]) {
+				_ = c.w.Flush()
+				return
+			}
+		}
+		if c.r.Buffered() == 0 {
+			if err = c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *conn[S]) readLine() ([]byte, error) {
+	line, err := c.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, protocolError("too big inline request")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// readCommand reads a command sent as an array of bulk strings, as clients do, or inline, as typed using telnet.
+func (c *conn[S]) readCommand() ([][]byte, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		// the line is only valid until the next read, while args may be stored.
+		args := bytes.Fields(line)
+		for i := range args {
+			args[i] = bytes.Clone(args[i])
+		}
+		return args, nil
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > maxArgs {
+		return nil, protocolError("invalid multibulk length")
+	}
+	args := make([][]byte, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err = c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, protocolError("expected '$'")
+		}
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > c.s.maxValueSize+maxInlineSize {
+			return nil, protocolError("invalid bulk length")
+		}
+		arg, err := c.readBulk(size)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// readBulk reads a bulk string of size bytes followed by CRLF, growing its buffer in chunks as data arrives.
+func (c *conn[S]) readBulk(size int) ([]byte, error) {
+	arg := make([]byte, 0, min(size+2, bulkChunkSize))
+	for remaining := size + 2; remaining > 0; {
+		n := min(remaining, bulkChunkSize)
+		arg = slices.Grow(arg, n)
+		if _, err := io.ReadFull(c.r, arg[len(arg):len(arg)+n]); err != nil {
+			return nil, err
+		}
+		arg = arg[:len(arg)+n]
+		remaining -= n
+	}
+	if !bytes.Equal(arg[size:], crlf) {
+		return nil, protocolError("expected CRLF")
+	}
+	return arg[:size], nil
+}
+
+func (c *conn[S]) simple(s string) {
+	_, _ = c.w.WriteString("+" + s + "\r\n")
+}
+
+func (c *conn[S]) error(s string) {
+	_, _ = c.w.WriteString("-" + s + "\r\n")
+}
+
+func (c *conn[S]) integer(n int64) {
+	_, _ = c.w.WriteString(":" + itoa(n) + "\r\n")
+}
+
+func (c *conn[S]) bulk(b []byte) {
+	_, _ = c.w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	_, _ = c.w.Write(b)
+	_, _ = c.w.Write(crlf)
+}
+
+func (c *conn[S]) null() {
+	_, _ = c.w.WriteString("$-1\r\n")
+}
+
+func (c *conn[S]) array(n int) {
+	_, _ = c.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (c *conn[S]) arity(cmd string) {
+	c.error("ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command")
+}
+
+// handle executes a command returning false if the connection should be closed.
+func (c *conn[S]) handle(cmd string, args [][]byte) bool {
+	switch cmd {
+	case "PING":
+		switch len(args) {
+		case 0:
+			c.simple("PONG")
+		case 1:
+			c.bulk(args[0])
+		default:
+			c.arity(cmd)
+		}
+	case "ECHO":
+		if len(args) != 1 {
+			c.arity(cmd)
+			return true
+		}
+		c.bulk(args[0])
+	case "GET":
+		if len(args) != 1 {
+			c.arity(cmd)
+			return true
+		}
+		c.get(args[0])
+	case "MGET":
+		if len(args) == 0 {
+			c.arity(cmd)
+			return true
+		}
+		c.array(len(args))
+		for _, key := range args {
+			c.get(key)
+		}
+	case "SET":
+		c.set(cmd, args)
+	case "MSET":
+		if len(args) == 0 || len(args)%2 != 0 {
+			c.arity(cmd)
+			return true
+		}
+		for i := 1; i < len(args); i += 2 {
+			if len(args[i]) > c.s.maxValueSize {
+				c.error(msgValueTooBig)
+				return true
+			}
+		}
+		c.s.mu.Lock()
+		for i := 0; i < len(args); i += 2 {
+			c.s.cache.SetWithMaxAge(string(args[i]), &Value{Data: args[i+1]}, 0)
+		}
+		rejected := c.s.rejected()
+		c.s.mu.Unlock()
+		if rejected {
+			c.error(msgOOM)
+			return true
+		}
+		c.simple("OK")
+	case "DEL", "UNLINK":
+		if len(args) == 0 {
+			c.arity(cmd)
+			return true
+		}
+		var n int64
+		c.s.mu.Lock()
+		for _, key := range args {
+			if _, found := c.s.lookup(string(key)); found {
+				c.s.cache.Remove(string(key))
+				n++
+			}
+		}
+		c.s.mu.Unlock()
+		c.integer(n)
+	case "EXISTS":
+		if len(args) == 0 {
+			c.arity(cmd)
+			return true
+		}
+		var n int64
+		for _, key := range args {
+			if _, found := c.s.lookup(string(key)); found {
+				n++
+			}
+		}
+		c.integer(n)
+	case "TTL", "PTTL":
+		if len(args) != 1 {
+			c.arity(cmd)
+			return true
+		}
+		value, found := c.s.lookup(string(args[0]))
+		switch {
+		case !found:
+			c.integer(-2)
+		case value.expires.IsZero():
+			c.integer(-1)
+		case cmd == "TTL":
+			c.integer(int64((time.Until(value.expires) + 500*time.Millisecond) / time.Second))
+		default:
+			c.integer(time.Until(value.expires).Milliseconds())
+		}
+	case "FLUSHDB", "FLUSHALL":
+		if len(args) > 1 || (len(args) == 1 && !strings.EqualFold(string(args[0]), "ASYNC") &&
+			!strings.EqualFold(string(args[0]), "SYNC")) {
+			c.error(msgSyntax)
+			return true
+		}
+		c.s.mu.Lock()
+		// accumulate the Stats delta first as clearing resets it.
+		c.s.stats.Sum(c.s.cache.Stats())
+		c.s.cache.Clear()
+		c.s.mu.Unlock()
+		c.simple("OK")
+	case "INFO":
+		var section string
+		if len(args) > 0 {
+			section = string(args[0])
+		}
+		c.bulk([]byte(c.s.info(section)))
+	case "SELECT":
+		if len(args) != 1 {
+			c.arity(cmd)
+			return true
+		}
+		if string(args[0]) != "0" {
+			c.error("ERR DB index is out of range")
+			return true
+		}
+		c.simple("OK")
+	case "COMMAND":
+		// enough for redis-cli, which requests command docs on connect
+		c.array(0)
+	case "CLIENT":
+		c.simple("OK")
+	case "QUIT":
+		c.simple("OK")
+		return false
+	default:
+		c.error("ERR unknown command '" + strings.ToLower(cmd) + "'")
+	}
+	return true
+}
+
+// lookup returns the keys value and if it exists.
+func (s *Server[S]) lookup(key string) (*Value, bool) {
+	option := s.cache.Get(key)
+	if option.IsNone() {
+		return nil, false
+	}
+	value := option.Unwrap()
+	if !value.expires.IsZero() && !time.Now().Before(value.expires) {
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *conn[S]) get(key []byte) {
+	if value, found := c.s.lookup(string(key)); found {
+		c.bulk(value.Data)
+	} else {
+		c.null()
+	}
+}
+
+// set handles SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-seconds |
+// PXAT unix-milliseconds | KEEPTTL].
+func (c *conn[S]) set(cmd string, args [][]byte) {
+	if len(args) < 2 {
+		c.arity(cmd)
+		return
+	}
+	key := string(args[0])
+	if len(args[1]) > c.s.maxValueSize {
+		c.error(msgValueTooBig)
+		return
+	}
+
+	var nx, xx, get, keepTTL, hasExpiry bool
+	var expires time.Time
+	for i := 2; i < len(args); i++ {
+		opt := strings.ToUpper(string(args[i]))
+		switch opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+			get = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpiry || i+1 >= len(args) {
+				c.error(msgSyntax)
+				return
+			}
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				c.error(msgNotInteger)
+				return
+			}
+			if n <= 0 {
+				c.error(msgInvalidExp)
+				return
+			}
+			hasExpiry = true
+			switch opt {
+			case "EX":
+				expires = time.Now().Add(time.Duration(n) * time.Second)
+			case "PX":
+				expires = time.Now().Add(time.Duration(n) * time.Millisecond)
+			case "EXAT":
+				expires = time.Unix(n, 0)
+			default:
+				expires = time.UnixMilli(n)
+			}
+		default:
+			c.error(msgSyntax)
+			return
+		}
+	}
+	if (nx && xx) || (keepTTL && hasExpiry) {
+		c.error(msgSyntax)
+		return
+	}
+
+	var rejected bool
+	c.s.mu.Lock()
+	current, found := c.s.lookup(key)
+	stored := !(nx && found) && !(xx && !found)
+	if stored {
+		if keepTTL && found {
+			expires = current.expires
+		}
+		var maxAge time.Duration
+		if !expires.IsZero() {
+			maxAge = time.Until(expires)
+		}
+		if maxAge < 0 || (!expires.IsZero() && maxAge == 0) {
+			c.s.cache.Remove(key)
+		} else {
+			c.s.cache.SetWithMaxAge(key, &Value{Data: args[1], expires: expires}, maxAge)
+			rejected = c.s.rejected()
+		}
+	}
+	c.s.mu.Unlock()
+
+	switch {
+	case rejected:
+		c.error(msgOOM)
+	case get && found:
+		c.bulk(current.Data)
+	case get || !stored:
+		c.null()
+	default:
+		c.simple("OK")
+	}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func utoa(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
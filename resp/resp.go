@@ -0,0 +1,209 @@
+package resp
+
+import (
+	"github.com/go-playground/cache/internal/netserver"
+	"github.com/go-playground/cache/internal/stats"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Version is reported as the redis_version by the INFO command.
+const Version = "7.0.0"
+
+// ErrServerClosed is returned by Serve and ListenAndServe after the Server has been closed.
+var ErrServerClosed = netserver.ErrServerClosed
+
+// Cache is the set of functions required to store values, satisfied by the lru and lfu ThreadSafeCache using string
+// keys and *Value values, S being their Stats type.
+//
+// Build the cache with Weigh as its Weigher to bound it by bytes rather than by key count.
+type Cache[S any] interface {
+	Get(key string) optionext.Option[*Value]
+	SetWithMaxAge(key string, value *Value, maxAge time.Duration)
+	Remove(key string)
+	Clear()
+	Stats() S
+}
+
+// Value is a stored string value.
+type Value struct {
+	// Data is the values data.
+	Data []byte
+
+	// expires is when the value expires or zero if it only expires according to the caches MaxAge.
+	expires time.Time
+}
+
+// Weigh returns the approximate size in bytes of a value, for use as the caches Weigher.
+func Weigh(key string, value *Value) int {
+	return len(key) + len(value.Data)
+}
+
+type builder[S any] struct {
+	server *Server[S]
+}
+
+// New initializes a builder to create a Server speaking the Redis serialization protocol backed by the provided
+// cache.
+//
+// The Server accumulates the caches Stats delta on every INFO command to report totals, as Redis does, and after every
+// SET and MSET to detect values the cache rejected for weighing more than its capacity, and so nothing else should
+// call the caches Stats function. Commands which must check for an existing key, such as EXISTS, TTL and
+// DEL, count as gets in the caches Stats.
+func New[S any](cache Cache[S]) *builder[S] {
+	return &builder[S]{
+		server: &Server[S]{
+			cache:        cache,
+			maxValueSize: 512 << 20,
+		},
+	}
+}
+
+// MaxValueSize sets the largest value, in bytes, which may be stored. It also bounds the length of each bulk string
+// argument a client may send, along with an allowance for those which are not values.
+//
+// Default is 512MiB, as Redis.
+func (b *builder[S]) MaxValueSize(size int) *builder[S] {
+	if size <= 0 {
+		panic("MaxValueSize must be a positive value")
+	}
+	b.server.maxValueSize = size
+	return b
+}
+
+// Build finalizes configuration and returns the Server for use.
+func (b *builder[S]) Build() (server *Server[S]) {
+	server = b.server
+	b.server = nil
+	server.started = time.Now()
+	return
+}
+
+// Server serves a subset of Redis commands, GET, SET with EX, PX, NX and XX, DEL, EXISTS, TTL, PTTL, MGET, MSET,
+// FLUSHDB, FLUSHALL, INFO, PING, ECHO, SELECT 0 and QUIT, on top of a cache for use with existing Redis clients and
+// tools during development and tests.
+//
+// Only database 0 exists and values are always strings. Keys set without an expiry report a TTL of -1 even though
+// they still expire according to the caches MaxAge.
+type Server[S any] struct {
+	cache        Cache[S]
+	maxValueSize int
+	started      time.Time
+
+	// mu serializes all writes so compound commands, such as SET with NX, are atomic.
+	mu sync.Mutex
+
+	stats            stats.Totals[S]
+	commands         atomic.Uint64
+	connectedClients atomic.Int64
+	totalConnections atomic.Uint64
+
+	srv netserver.Server
+}
+
+// ListenAndServe listens on the network, tcp or unix, and address then calls Serve.
+func (s *Server[S]) ListenAndServe(network, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener, serving each on its own goroutine, until the Server is closed.
+func (s *Server[S]) Serve(l net.Listener) error {
+	return s.srv.Serve(l, s.serve)
+}
+
+// Close stops all listeners, closes all connections and waits for them to finish.
+func (s *Server[S]) Close() error {
+	return s.srv.Close()
+}
+
+// infoNames maps cache Stats fields to their Redis INFO names. Fields not listed are reported in snake case, prefixed
+// with cache_, and fields mapped to an empty name are omitted.
+var infoNames = map[string]string{
+	"Capacity":          "maxmemory",
+	"Len":               "keys",
+	"Weight":            "used_memory_dataset",
+	"Hits":              "keyspace_hits",
+	"Misses":            "keyspace_misses",
+	"CapacityEvictions": "evicted_keys",
+	"Expirations":       "expired_keys",
+	"Evictions":         "",
+}
+
+// rejected accumulates the caches Stats delta into the running totals, reporting if the cache rejected any value just
+// set for weighing more than its capacity. It must be called with the write lock held.
+func (s *Server[S]) rejected() bool {
+	delta := s.cache.Stats()
+	s.stats.Sum(delta)
+	return stats.Counter(delta, "Rejections") > 0
+}
+
+// info returns the INFO response for the section, empty returning all sections.
+func (s *Server[S]) info(section string) string {
+	section = strings.ToLower(section)
+	all := section == "" || section == "all" || section == "default" || section == "everything"
+	now := time.Now()
+
+	var sb strings.Builder
+	line := func(name, value string) {
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(value)
+		sb.WriteString("\r\n")
+	}
+	if all || section == "server" {
+		sb.WriteString("# Server\r\n")
+		line("redis_version", Version)
+		line("redis_mode", "standalone")
+		line("uptime_in_seconds", itoa(int64(now.Sub(s.started)/time.Second)))
+		sb.WriteString("\r\n")
+	}
+	if all || section == "clients" {
+		sb.WriteString("# Clients\r\n")
+		line("connected_clients", itoa(s.connectedClients.Load()))
+		sb.WriteString("\r\n")
+	}
+	if all || section == "stats" || section == "keyspace" {
+		var keys string
+		statsSection := all || section == "stats"
+		if statsSection {
+			sb.WriteString("# Stats\r\n")
+			line("total_connections_received", utoa(s.totalConnections.Load()))
+			line("total_commands_processed", utoa(s.commands.Load()))
+		}
+		s.mu.Lock()
+		totals := s.stats.Add(s.cache.Stats())
+		s.mu.Unlock()
+		for _, f := range totals {
+			name, found := infoNames[f.Name]
+			if !found {
+				name = "cache_" + stats.SnakeCase(f.Name)
+			}
+			if name == "keys" {
+				keys = f.Value
+				continue
+			}
+			if name != "" && statsSection {
+				line(name, f.Value)
+			}
+		}
+		if statsSection {
+			sb.WriteString("\r\n")
+		}
+		if all || section == "keyspace" {
+			sb.WriteString("# Keyspace\r\n")
+			if keys != "" && keys != "0" {
+				line("db0", "keys="+keys+",expires=0,avg_ttl=0")
+			}
+			sb.WriteString("\r\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\r\n")
+}
@@ -0,0 +1,206 @@
+package resp
+
+import (
+	"bufio"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func start[S any](t *testing.T, cache Cache[S]) (*Server[S], *client) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Equal(t, err, nil)
+	server := New(cache).MaxValueSize(16).Build()
+	go func() { _ = server.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	Equal(t, err, nil)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = server.Close()
+	})
+	return server, &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends the command as an array of bulk strings and returns the reply rendered as a string.
+func (c *client) do(args ...string) string {
+	var sb strings.Builder
+	sb.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		sb.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	_, err := c.conn.Write([]byte(sb.String()))
+	Equal(c.t, err, nil)
+	return c.read()
+}
+
+func (c *client) read() string {
+	line, err := c.r.ReadString('\n')
+	Equal(c.t, err, nil)
+	line = strings.TrimRight(line, "\r\n")
+	switch line[0] {
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return "(nil)"
+		}
+		b := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, b)
+		Equal(c.t, err, nil)
+		return string(b[:n])
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		items := make([]string, n)
+		for i := range items {
+			items[i] = c.read()
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	default:
+		return line
+	}
+}
+
+func TestServer(t *testing.T) {
+	cache := lru.New[string, *Value](1 << 10).Weigher(Weigh).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	Equal(t, c.do("PING"), "+PONG")
+	Equal(t, c.do("ping", "hi"), "hi")
+	Equal(t, c.do("ECHO", "x"), "x")
+	Equal(t, c.do("SET", "a", "1"), "+OK")
+	Equal(t, c.do("GET", "a"), "1")
+	Equal(t, c.do("SET", "a", "2", "NX"), "(nil)")
+	Equal(t, c.do("SET", "b", "2", "XX"), "(nil)")
+	Equal(t, c.do("SET", "a", "3", "XX", "GET"), "1")
+	Equal(t, c.do("MSET", "b", "4", "c", "5"), "+OK")
+	Equal(t, c.do("MGET", "a", "b", "z", "c"), "[3,4,(nil),5]")
+	Equal(t, c.do("EXISTS", "a", "a", "z"), ":2")
+	Equal(t, c.do("DEL", "a", "z"), ":1")
+	Equal(t, c.do("GET", "a"), "(nil)")
+
+	// ttl
+	Equal(t, c.do("TTL", "z"), ":-2")
+	Equal(t, c.do("TTL", "b"), ":-1")
+	Equal(t, c.do("SET", "e", "x", "EX", "100"), "+OK")
+	Equal(t, c.do("TTL", "e"), ":100")
+	Equal(t, c.do("SET", "e", "y", "KEEPTTL"), "+OK")
+	Equal(t, c.do("TTL", "e"), ":100")
+	Equal(t, c.do("SET", "p", "x", "PX", "50"), "+OK")
+	Equal(t, c.do("GET", "p"), "x")
+	time.Sleep(60 * time.Millisecond)
+	Equal(t, c.do("GET", "p"), "(nil)")
+	Equal(t, c.do("TTL", "p"), ":-2")
+
+	// errors
+	Equal(t, c.do("SET", "a"), "-ERR wrong number of arguments for 'set' command")
+	Equal(t, c.do("SET", "a", "1", "EX", "x"), "-"+msgNotInteger)
+	Equal(t, c.do("SET", "a", "1", "EX", "0"), "-"+msgInvalidExp)
+	Equal(t, c.do("SET", "a", "1", "NX", "XX"), "-"+msgSyntax)
+	Equal(t, c.do("SET", "a", strings.Repeat("x", 17)), "-"+msgValueTooBig)
+	Equal(t, c.do("SELECT", "1"), "-ERR DB index is out of range")
+	Equal(t, c.do("SELECT", "0"), "+OK")
+	Equal(t, c.do("BOGUS"), "-ERR unknown command 'bogus'")
+
+	// inline
+	_, err := c.conn.Write([]byte("GET b\r\n"))
+	Equal(t, err, nil)
+	Equal(t, c.read(), "4")
+	_, err = c.conn.Write([]byte("SET foo barbarbar\r\n"))
+	Equal(t, err, nil)
+	Equal(t, c.read(), "+OK")
+	_, err = c.conn.Write([]byte("SET zzz XXXXXXXXX\r\n"))
+	Equal(t, err, nil)
+	Equal(t, c.read(), "+OK")
+	Equal(t, c.do("GET", "foo"), "barbarbar")
+	Equal(t, c.do("DEL", "foo", "zzz"), ":2")
+
+	// bulk strings are read in chunks.
+	Equal(t, c.do("GET", strings.Repeat("k", bulkChunkSize+10)), "(nil)")
+
+	info := c.do("INFO")
+	Equal(t, strings.Contains(info, "# Stats\r\n"), true)
+	Equal(t, strings.Contains(info, "keyspace_hits:"), true)
+	Equal(t, strings.Contains(info, "maxmemory:1024\r\n"), true)
+	Equal(t, strings.Contains(info, "cache_negative_hits:0\r\n"), true)
+	Equal(t, strings.Contains(info, "db0:keys=3,"), true)
+	Equal(t, strings.Contains(info, "connected_clients:1\r\n"), true)
+
+	info = c.do("INFO", "keyspace")
+	Equal(t, strings.HasPrefix(info, "# Keyspace\r\ndb0:keys=3,"), true)
+	Equal(t, strings.Contains(info, "# Stats"), false)
+
+	Equal(t, c.do("FLUSHDB"), "+OK")
+	Equal(t, c.do("MGET", "b", "c", "e"), "[(nil),(nil),(nil)]")
+	Equal(t, c.do("QUIT"), "+OK")
+}
+
+func TestServerStatsAccumulate(t *testing.T) {
+	cache := lfu.New[string, *Value](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	Equal(t, c.do("GET", "a"), "(nil)")
+	Equal(t, strings.Contains(c.do("INFO", "stats"), "keyspace_misses:1\r\n"), true)
+	Equal(t, c.do("GET", "a"), "(nil)")
+	Equal(t, strings.Contains(c.do("INFO", "stats"), "keyspace_misses:2\r\n"), true)
+}
+
+func TestServerRejected(t *testing.T) {
+	cache := lru.New[string, *Value](8).Weigher(Weigh).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	// values weighing more than the capacity are rejected by the cache.
+	Equal(t, c.do("SET", "a", "01234567"), "-"+msgOOM)
+	Equal(t, c.do("GET", "a"), "(nil)")
+	Equal(t, c.do("MSET", "a", "1", "b", "01234567"), "-"+msgOOM)
+	Equal(t, c.do("GET", "b"), "(nil)")
+	Equal(t, c.do("SET", "a", "0123456"), "+OK")
+	Equal(t, strings.Contains(c.do("INFO", "stats"), "cache_rejections:2\r\n"), true)
+}
+
+func TestServerFlushStats(t *testing.T) {
+	cache := lru.New[string, *Value](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	// counts since the last INFO command survive the flush resetting the caches Stats.
+	Equal(t, c.do("SET", "a", "1"), "+OK")
+	Equal(t, c.do("GET", "a"), "1")
+	Equal(t, c.do("GET", "b"), "(nil)")
+	Equal(t, c.do("FLUSHDB"), "+OK")
+	info := c.do("INFO", "stats")
+	Equal(t, strings.Contains(info, "keyspace_hits:1\r\n"), true)
+	Equal(t, strings.Contains(info, "cache_sets:1\r\n"), true)
+}
+
+func TestServerProtocolError(t *testing.T) {
+	cache := lru.New[string, *Value](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	_, err := c.conn.Write([]byte("*1\r\n+GET\r\n"))
+	Equal(t, err, nil)
+	Equal(t, strings.HasPrefix(c.read(), "-ERR Protocol error"), true)
+	_, err = c.r.ReadString('\n')
+	Equal(t, err, io.EOF)
+}
+
+func TestServerBulkTooLong(t *testing.T) {
+	cache := lru.New[string, *Value](10).BuildThreadSafe()
+	_, c := start(t, cache)
+
+	_, err := c.conn.Write([]byte("*2\r\n$3\r\nGET\r\n$536870912\r\n"))
+	Equal(t, err, nil)
+	Equal(t, c.read(), "-ERR Protocol error: invalid bulk length")
+	_, err = c.r.ReadString('\n')
+	Equal(t, err, io.EOF)
+}
@@ -0,0 +1,457 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/cache/internal/cachelog"
+	syncext "github.com/go-playground/pkg/v5/sync"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/maphash"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Set and Remove when writing behind after the Backed cache has been closed.
+var ErrClosed = errors.New("backed cache closed")
+
+type builder[K comparable, V any] struct {
+	backed *Backed[K, V]
+}
+
+// New initializes a builder to create a Backed cache reading through to and writing to the provided Store.
+func New[K comparable, V any](cache Cache[K, V], store Store[K, V]) *builder[K, V] {
+	return &builder[K, V]{
+		backed: &Backed[K, V]{
+			cache:   cache,
+			store:   store,
+			seed:    maphash.MakeSeed(),
+			onError: func(error) {},
+			loads:   syncext.NewMutex2(make(map[K]*call[V])),
+			gens:    syncext.NewMutex2(make(map[K]*generation)),
+			pending: syncext.NewMutex2(&pending[K, V]{ops: make(map[K]op[V])}),
+		},
+	}
+}
+
+// WriteBehind sets Set and Remove to update the cache immediately and batch the writes and deletes to the Store,
+// flushing every interval or once batchSize keys are pending, whichever comes first. Multiple writes to the same key
+// within a batch are coalesced into the last one.
+//
+// Once closed, Set and Remove return ErrClosed rather than queueing operations which would never be written.
+//
+// Default is write-through, where the Store is updated before the cache and any error returned to the caller.
+func (b *builder[K, V]) WriteBehind(batchSize int, interval time.Duration) *builder[K, V] {
+	if batchSize <= 0 || interval <= 0 {
+		panic("WriteBehind batchSize and interval must be positive values")
+	}
+	b.backed.batchSize = batchSize
+	b.backed.interval = interval
+	return b
+}
+
+// MissingMaxAge enables negative caching, recording keys not found in the Store as known to be absent for the
+// provided maxAge so that repeated lookups do not reach the Store. The cache must implement SetMissing and Lookup,
+// as the lru and lfu caches do.
+//
+// Default is disabled.
+func (b *builder[K, V]) MissingMaxAge(maxAge time.Duration) *builder[K, V] {
+	negative, ok := b.backed.cache.(negativeCache[K, V])
+	if !ok {
+		panic("MissingMaxAge requires a cache implementing SetMissing and Lookup")
+	}
+	if maxAge <= 0 {
+		panic("MissingMaxAge must be a positive value")
+	}
+	b.backed.negative = negative
+	b.backed.missingMaxAge = maxAge
+	return b
+}
+
+// OnError sets a function to be called with errors encountered while flushing write-behind batches in the
+// background.
+//
+// Default ignores errors.
+func (b *builder[K, V]) OnError(fn func(err error)) *builder[K, V] {
+	b.backed.onError = fn
+	return b
+}
+
+// Logger sets a logger to emit debug level records for errors loading from the Store. Use logger.With to identify the
+// cache. Nothing is logged when unset.
+//
+// Default is unset.
+func (b *builder[K, V]) Logger(logger *slog.Logger) *builder[K, V] {
+	b.backed.logger = cachelog.New(logger)
+	return b
+}
+
+// Build finalizes configuration and returns the Backed cache for use, starting the write-behind flusher if enabled.
+func (b *builder[K, V]) Build() (backed *Backed[K, V]) {
+	backed = b.backed
+	b.backed = nil
+	if backed.batchSize > 0 {
+		backed.flush = make(chan struct{}, 1)
+		backed.done = make(chan struct{})
+		backed.stopped = make(chan struct{})
+		go backed.run()
+	}
+	return
+}
+
+// op is a pending write-behind operation.
+type op[V any] struct {
+	value  V
+	delete bool
+	seq    uint64
+}
+
+// pending holds the write-behind operations not yet applied to the Store. Operations remain until written so that
+// reads do not load stale values from the Store mid flush.
+type pending[K comparable, V any] struct {
+	ops    map[K]op[V]
+	seq    uint64
+	closed bool
+}
+
+// call is an in-flight Store load shared by concurrent Gets of the same key.
+type call[V any] struct {
+	wg     sync.WaitGroup
+	result optionext.Option[V]
+	err    error
+}
+
+// generation counts the in-flight Store loads of a key and is bumped by every Set and Remove of the key, so that a
+// load racing a write is not cached over it.
+type generation struct {
+	loads int
+	n     uint64
+}
+
+// Backed is a cache-aside abstraction over a Cache and a Store which reads through to the Store on a miss, writes
+// through or behind to the Store on Set and deletes through to the Store on Remove.
+//
+// Concurrent Gets missing the same key share a single Store load, while Sets and Removes of the same key are applied
+// to the Store and cache one at a time. Backed is safe for concurrent use provided the Cache and Store are.
+type Backed[K comparable, V any] struct {
+	cache         Cache[K, V]
+	negative      negativeCache[K, V]
+	store         Store[K, V]
+	missingMaxAge time.Duration
+	onError       func(err error)
+	logger        *cachelog.Logger
+	loads         syncext.Mutex2[map[K]*call[V]]
+	gens          syncext.Mutex2[map[K]*generation]
+	seed          maphash.Seed
+	writes        [64]sync.Mutex
+	batchSize     int
+	interval      time.Duration
+	pending       syncext.Mutex2[*pending[K, V]]
+	flushing      sync.Mutex
+	flush         chan struct{}
+	done          chan struct{}
+	stopped       chan struct{}
+	closing       sync.Once
+}
+
+// Get attempts to find an existing cache entry by key, loading it from the Store and caching it on a miss.
+// It returns an Option you must check before using the underlying value.
+func (b *Backed[K, V]) Get(ctx context.Context, key K) (result optionext.Option[V], err error) {
+	if b.negative != nil {
+		var missing bool
+		if result, missing = b.negative.Lookup(key); result.IsSome() || missing {
+			return
+		}
+	} else if result = b.cache.Get(key); result.IsSome() {
+		return
+	}
+	// begun before checking pending operations so a write-behind Set or Remove either is pending or bumps gen.
+	gen := b.begin(key)
+	if result, found := b.pendingOp(key); found {
+		b.end(key, gen, result, false)
+		return result, nil
+	}
+
+	guard := b.loads.Lock()
+	if c, found := guard.T[key]; found {
+		guard.Unlock()
+		b.end(key, gen, result, false)
+		c.wg.Wait()
+		return c.result, c.err
+	}
+	c := new(call[V])
+	c.wg.Add(1)
+	guard.T[key] = c
+	guard.Unlock()
+
+	c.result, c.err = b.store.Load(ctx, key)
+	b.end(key, gen, c.result, c.err == nil)
+	if c.err != nil && b.logger != nil {
+		b.logger.LoadFailed(ctx, c.err, slog.Any("key", key))
+	}
+
+	guard = b.loads.Lock()
+	delete(guard.T, key)
+	guard.Unlock()
+	c.wg.Done()
+	return c.result, c.err
+}
+
+// GetMany returns the values for all keys which exist, loading those not cached from the Store in a single LoadMany.
+func (b *Backed[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	var misses []K
+	var gens []uint64
+
+	for _, key := range keys {
+		var result optionext.Option[V]
+		if b.negative != nil {
+			var missing bool
+			if result, missing = b.negative.Lookup(key); missing {
+				continue
+			}
+		} else {
+			result = b.cache.Get(key)
+		}
+		if result.IsNone() {
+			gen := b.begin(key)
+			var found bool
+			if result, found = b.pendingOp(key); !found {
+				misses = append(misses, key)
+				gens = append(gens, gen)
+				continue
+			}
+			b.end(key, gen, result, false)
+		}
+		if result.IsSome() {
+			values[key] = result.Unwrap()
+		}
+	}
+	if len(misses) == 0 {
+		return values, nil
+	}
+
+	loaded, err := b.store.LoadMany(ctx, misses)
+	if err != nil {
+		for i, key := range misses {
+			b.end(key, gens[i], optionext.None[V](), false)
+		}
+		if b.logger != nil {
+			b.logger.LoadFailed(ctx, err, slog.Int("keys", len(misses)))
+		}
+		return nil, err
+	}
+	for i, key := range misses {
+		if value, found := loaded[key]; found {
+			values[key] = value
+			b.end(key, gens[i], optionext.Some(value), true)
+		} else {
+			b.end(key, gens[i], optionext.None[V](), true)
+		}
+	}
+	return values, nil
+}
+
+// begin registers a Store load of the key, returning the generation to end it with.
+func (b *Backed[K, V]) begin(key K) uint64 {
+	guard := b.gens.Lock()
+	defer guard.Unlock()
+	g, found := guard.T[key]
+	if !found {
+		g = new(generation)
+		guard.T[key] = g
+	}
+	g.loads++
+	return g.n
+}
+
+// end ends a Store load of the key, caching its result if loaded and the key has not been set or removed since the
+// load began.
+func (b *Backed[K, V]) end(key K, gen uint64, result optionext.Option[V], loaded bool) {
+	guard := b.gens.Lock()
+	defer guard.Unlock()
+	g := guard.T[key]
+	if loaded && g.n == gen {
+		if result.IsSome() {
+			b.cache.Set(key, result.Unwrap())
+		} else if b.negative != nil {
+			b.negative.SetMissing(key, b.missingMaxAge)
+		}
+	}
+	if g.loads--; g.loads == 0 {
+		delete(guard.T, key)
+	}
+}
+
+// bump invalidates any Store load of the key in flight. Called before updating the cache, a load ending before is
+// overwritten while one ending after is discarded.
+func (b *Backed[K, V]) bump(key K) {
+	guard := b.gens.Lock()
+	if g, found := guard.T[key]; found {
+		g.n++
+	}
+	guard.Unlock()
+}
+
+// pendingOp returns the result of a pending write-behind operation for the key, if any, which the Store does not yet
+// reflect.
+func (b *Backed[K, V]) pendingOp(key K) (result optionext.Option[V], found bool) {
+	if b.batchSize == 0 {
+		return
+	}
+	guard := b.pending.Lock()
+	o, found := guard.T.ops[key]
+	guard.Unlock()
+	if found && !o.delete {
+		result = optionext.Some(o.value)
+	}
+	return
+}
+
+// Set sets an item into the cache and Store.
+//
+// When writing through, the Store is written first and on error the cache is left untouched. When writing behind the
+// error is nil, unless closed, and any Store error is reported to the OnError function.
+func (b *Backed[K, V]) Set(ctx context.Context, key K, value V) error {
+	mu := b.lock(key)
+	defer mu.Unlock()
+
+	if b.batchSize > 0 {
+		if err := b.enqueue(key, op[V]{value: value}); err != nil {
+			return err
+		}
+		b.bump(key)
+		b.cache.Set(key, value)
+		return nil
+	}
+	if err := b.store.Write(ctx, key, value); err != nil {
+		return err
+	}
+	b.bump(key)
+	b.cache.Set(key, value)
+	return nil
+}
+
+// Remove removes the item matching the provided key from the cache and Store.
+//
+// When writing through, the Store is deleted from first and on error the cache is left untouched. When writing behind
+// the error is nil, unless closed, and any Store error is reported to the OnError function.
+func (b *Backed[K, V]) Remove(ctx context.Context, key K) error {
+	mu := b.lock(key)
+	defer mu.Unlock()
+
+	if b.batchSize > 0 {
+		if err := b.enqueue(key, op[V]{delete: true}); err != nil {
+			return err
+		}
+		b.bump(key)
+		b.cache.Remove(key)
+		return nil
+	}
+	if err := b.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	b.bump(key)
+	b.cache.Remove(key)
+	return nil
+}
+
+// lock locks and returns the mutex serializing Sets and Removes of the key, so the Store and cache are updated in the
+// same order.
+func (b *Backed[K, V]) lock(key K) *sync.Mutex {
+	mu := &b.writes[maphash.Comparable(b.seed, key)%uint64(len(b.writes))]
+	mu.Lock()
+	return mu
+}
+
+func (b *Backed[K, V]) enqueue(key K, o op[V]) error {
+	guard := b.pending.Lock()
+	if guard.T.closed {
+		guard.Unlock()
+		return ErrClosed
+	}
+	guard.T.seq++
+	o.seq = guard.T.seq
+	guard.T.ops[key] = o
+	n := len(guard.T.ops)
+	guard.Unlock()
+
+	if n >= b.batchSize {
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *Backed[K, V]) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.flush:
+		}
+		if err := b.Flush(context.Background()); err != nil {
+			b.onError(err)
+		}
+	}
+}
+
+// Flush writes all pending write-behind operations to the Store, returning any errors joined together. Failed
+// operations remain pending, to be retried by the next Flush.
+func (b *Backed[K, V]) Flush(ctx context.Context) (err error) {
+	// serialize flushes so operations on the same key cannot be applied out of order.
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+
+	guard := b.pending.Lock()
+	batch := maps.Clone(guard.T.ops)
+	guard.Unlock()
+
+	for key, o := range batch {
+		var e error
+		if o.delete {
+			e = b.store.Delete(ctx, key)
+		} else {
+			e = b.store.Write(ctx, key, o.value)
+		}
+		if e != nil {
+			err = errors.Join(err, e)
+			delete(batch, key)
+		}
+	}
+
+	guard = b.pending.Lock()
+	for key, o := range batch {
+		// only remove if not superseded by a newer operation during the flush.
+		if guard.T.ops[key].seq == o.seq {
+			delete(guard.T.ops, key)
+		}
+	}
+	guard.Unlock()
+	return
+}
+
+// Close stops the write-behind flusher, if enabled, and flushes any pending operations, after which Sets and Removes
+// return ErrClosed. Calling Close again only flushes, retrying any operations which failed.
+func (b *Backed[K, V]) Close(ctx context.Context) error {
+	if b.batchSize == 0 {
+		return nil
+	}
+	b.closing.Do(func() {
+		guard := b.pending.Lock()
+		guard.T.closed = true
+		guard.Unlock()
+		close(b.done)
+		<-b.stopped
+	})
+	return b.Flush(ctx)
+}
@@ -0,0 +1,359 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counting wraps a Store counting the calls made to it.
+type counting[K comparable, V any] struct {
+	Store[K, V]
+	loads, loadManys, writes, deletes atomic.Int64
+	err, loadErr                      error
+}
+
+func (c *counting[K, V]) Load(ctx context.Context, key K) (optionext.Option[V], error) {
+	c.loads.Add(1)
+	if c.loadErr != nil {
+		return optionext.None[V](), c.loadErr
+	}
+	return c.Store.Load(ctx, key)
+}
+
+func (c *counting[K, V]) LoadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	c.loadManys.Add(1)
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+	return c.Store.LoadMany(ctx, keys)
+}
+
+func (c *counting[K, V]) Write(ctx context.Context, key K, value V) error {
+	c.writes.Add(1)
+	if c.err != nil {
+		return c.err
+	}
+	return c.Store.Write(ctx, key, value)
+}
+
+func (c *counting[K, V]) Delete(ctx context.Context, key K) error {
+	c.deletes.Add(1)
+	if c.err != nil {
+		return c.err
+	}
+	return c.Store.Delete(ctx, key)
+}
+
+// blocking wraps a Store blocking loads, once loaded, until released so writes can race them.
+type blocking[K comparable, V any] struct {
+	Store[K, V]
+	loaded, release chan struct{}
+}
+
+func (b *blocking[K, V]) Load(ctx context.Context, key K) (optionext.Option[V], error) {
+	result, err := b.Store.Load(ctx, key)
+	b.loaded <- struct{}{}
+	<-b.release
+	return result, err
+}
+
+func (b *blocking[K, V]) LoadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	values, err := b.Store.LoadMany(ctx, keys)
+	b.loaded <- struct{}{}
+	<-b.release
+	return values, err
+}
+
+// blockingWrites wraps a Store blocking writes, once started, until released so Sets can race them.
+type blockingWrites[K comparable, V any] struct {
+	Store[K, V]
+	writing chan V
+	release chan struct{}
+}
+
+func (b *blockingWrites[K, V]) Write(ctx context.Context, key K, value V) error {
+	b.writing <- value
+	<-b.release
+	return b.Store.Write(ctx, key, value)
+}
+
+func TestBackedBadConfig(t *testing.T) {
+	PanicMatches(t, func() {
+		New[string, int](lru.New[string, int](10).BuildThreadSafe(), NewMemory[string, int]()).WriteBehind(0, time.Second)
+	}, "WriteBehind batchSize and interval must be positive values")
+	PanicMatches(t, func() {
+		New[string, int](lru.New[string, int](10).BuildThreadSafe(), NewMemory[string, int]()).MissingMaxAge(0)
+	}, "MissingMaxAge must be a positive value")
+}
+
+func TestBackedWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	s := &counting[string, int]{Store: NewMemory[string, int]()}
+	_ = s.Store.Write(ctx, "1", 1)
+	c := lru.New[string, int](10).BuildThreadSafe()
+	b := New[string, int](c, s).Build()
+
+	// read-through
+	result, err := b.Get(ctx, "1")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.Some(1))
+	result, err = b.Get(ctx, "1")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.Some(1))
+	Equal(t, s.loads.Load(), int64(1))
+
+	// not found, no negative caching
+	result, err = b.Get(ctx, "2")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.None[int]())
+	_, _ = b.Get(ctx, "2")
+	Equal(t, s.loads.Load(), int64(3))
+
+	// write-through
+	Equal(t, b.Set(ctx, "2", 2), nil)
+	Equal(t, c.Get("2"), optionext.Some(2))
+	Equal(t, s.Store.(*Memory[string, int]).Len(), 2)
+
+	// delete-through
+	Equal(t, b.Remove(ctx, "1"), nil)
+	Equal(t, c.Get("1"), optionext.None[int]())
+	Equal(t, s.Store.(*Memory[string, int]).Len(), 1)
+
+	// store errors leave the cache untouched
+	s.err = errors.New("unavailable")
+	Equal(t, b.Set(ctx, "2", 20), s.err)
+	Equal(t, b.Remove(ctx, "2"), s.err)
+	Equal(t, c.Get("2"), optionext.Some(2))
+
+	// batch loading
+	s.err = nil
+	_ = s.Store.Write(ctx, "3", 3)
+	_ = s.Store.Write(ctx, "4", 4)
+	values, err := b.GetMany(ctx, []string{"2", "3", "4", "5"})
+	Equal(t, err, nil)
+	Equal(t, values, map[string]int{"2": 2, "3": 3, "4": 4})
+	Equal(t, s.loadManys.Load(), int64(1))
+	Equal(t, c.Get("3"), optionext.Some(3))
+}
+
+func TestBackedLogger(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	s := &counting[string, int]{Store: NewMemory[string, int](), loadErr: errors.New("unavailable")}
+	b := New[string, int](lru.New[string, int](10).BuildThreadSafe(), s).Logger(logger).Build()
+
+	_, err := b.Get(ctx, "1")
+	Equal(t, err, s.loadErr)
+	_, err = b.GetMany(ctx, []string{"1", "2"})
+	Equal(t, err, s.loadErr)
+	Equal(t, buf.String(), `level=DEBUG msg="cache load failed" key=1 error=unavailable
+level=DEBUG msg="cache load failed" keys=2 error=unavailable
+`)
+}
+
+func TestBackedMissingMaxAge(t *testing.T) {
+	ctx := context.Background()
+	s := &counting[string, int]{Store: NewMemory[string, int]()}
+	c := lfu.New[string, int](10).BuildThreadSafe()
+	b := New[string, int](c, s).MissingMaxAge(time.Hour).Build()
+
+	for i := 0; i < 3; i++ {
+		result, err := b.Get(ctx, "1")
+		Equal(t, err, nil)
+		Equal(t, result, optionext.None[int]())
+	}
+	Equal(t, s.loads.Load(), int64(1))
+
+	values, err := b.GetMany(ctx, []string{"1", "2"})
+	Equal(t, err, nil)
+	Equal(t, len(values), 0)
+	values, err = b.GetMany(ctx, []string{"1", "2"})
+	Equal(t, err, nil)
+	Equal(t, len(values), 0)
+	Equal(t, s.loadManys.Load(), int64(1))
+
+	Equal(t, b.Set(ctx, "1", 1), nil)
+	result, err := b.Get(ctx, "1")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.Some(1))
+	Equal(t, c.Stats().NegativeHits, uint(5))
+}
+
+func TestBackedWriteBehind(t *testing.T) {
+	ctx := context.Background()
+	s := &counting[string, int]{Store: NewMemory[string, int]()}
+	c := lru.New[string, int](1).BuildThreadSafe()
+
+	b := New[string, int](c, s).WriteBehind(100, time.Hour).Build()
+
+	Equal(t, b.Set(ctx, "1", 1), nil)
+	Equal(t, b.Set(ctx, "1", 10), nil)
+	Equal(t, b.Set(ctx, "2", 2), nil) // evicts 1 from the cache
+	Equal(t, s.writes.Load(), int64(0))
+
+	// pending writes are served rather than loading stale values from the store
+	result, err := b.Get(ctx, "1")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.Some(10))
+	Equal(t, s.loads.Load(), int64(0))
+
+	Equal(t, b.Remove(ctx, "2"), nil)
+	result, err = b.Get(ctx, "2")
+	Equal(t, err, nil)
+	Equal(t, result, optionext.None[int]())
+
+	Equal(t, b.Flush(ctx), nil)
+	Equal(t, s.writes.Load(), int64(1))
+	Equal(t, s.deletes.Load(), int64(1))
+	stored, _ := s.Load(ctx, "1")
+	Equal(t, stored, optionext.Some(10))
+
+	// flushing in the background once batch size reached, reporting errors
+	s.err = errors.New("unavailable")
+	errs := make(chan error, 1)
+	b2 := New[string, int](c, s).WriteBehind(2, time.Hour).OnError(func(err error) {
+		errs <- err
+	}).Build()
+	Equal(t, b2.Set(ctx, "3", 3), nil)
+	Equal(t, b2.Set(ctx, "4", 4), nil)
+	Equal(t, errors.Is(<-errs, s.err), true)
+	Equal(t, s.writes.Load(), int64(3))
+
+	// failed operations remain pending and are retried, writes being rejected once closed.
+	Equal(t, errors.Is(b2.Close(ctx), s.err), true)
+	Equal(t, s.writes.Load(), int64(5))
+	Equal(t, b2.Set(ctx, "5", 5), ErrClosed)
+	Equal(t, b2.Remove(ctx, "3"), ErrClosed)
+	s.err = nil
+	Equal(t, b2.Close(ctx), nil)
+	stored, _ = s.Load(ctx, "3")
+	Equal(t, stored, optionext.Some(3))
+	stored, _ = s.Load(ctx, "5")
+	Equal(t, stored, optionext.None[int]())
+	Equal(t, b2.Close(ctx), nil)
+	Equal(t, s.writes.Load(), int64(7))
+}
+
+func TestBackedWriteOrder(t *testing.T) {
+	ctx := context.Background()
+	s := &blockingWrites[string, int]{Store: NewMemory[string, int](), writing: make(chan int), release: make(chan struct{})}
+	c := lru.New[string, int](10).BuildThreadSafe()
+	b := New[string, int](c, s).Build()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = b.Set(ctx, "1", 1)
+	}()
+	Equal(t, <-s.writing, 1)
+
+	// the second Set of the key must wait for the first to update the cache before writing to the Store.
+	done2 := make(chan struct{})
+	go func() {
+		defer close(done2)
+		_ = b.Set(ctx, "1", 2)
+	}()
+	var raced bool
+	select {
+	case <-s.writing:
+		raced = true
+	case <-time.After(50 * time.Millisecond):
+	}
+	Equal(t, raced, false)
+	s.release <- struct{}{}
+	<-done
+	Equal(t, <-s.writing, 2)
+	s.release <- struct{}{}
+	<-done2
+
+	stored, _ := s.Load(ctx, "1")
+	Equal(t, stored, optionext.Some(2))
+	Equal(t, c.Get("1"), optionext.Some(2))
+}
+
+func TestBackedConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	s := &counting[string, int]{Store: NewMemory[string, int]()}
+	_ = s.Store.Write(ctx, "1", 1)
+	b := New[string, int](lru.New[string, int](10).BuildThreadSafe(), s).Build()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := b.Get(ctx, "1")
+			if err != nil || result != optionext.Some(1) {
+				panic("undefined behaviour")
+			}
+		}()
+	}
+	wg.Wait()
+	Equal(t, s.loads.Load() <= 10, true)
+}
+
+func TestBackedStaleLoad(t *testing.T) {
+	ctx := context.Background()
+	s := &blocking[string, int]{Store: NewMemory[string, int](), loaded: make(chan struct{}), release: make(chan struct{})}
+	_ = s.Store.Write(ctx, "1", 1)
+	c := lru.New[string, int](10).BuildThreadSafe()
+
+	for _, writeBehind := range []bool{false, true} {
+		builder := New[string, int](c, s)
+		if writeBehind {
+			builder.WriteBehind(100, time.Hour)
+		}
+		b := builder.Build()
+
+		// the load completes after the Set so must not overwrite it.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = b.Get(ctx, "1")
+		}()
+		<-s.loaded
+		Equal(t, b.Set(ctx, "1", 2), nil)
+		s.release <- struct{}{}
+		<-done
+		Equal(t, c.Get("1"), optionext.Some(2))
+
+		// nor recache a removed key.
+		Equal(t, b.Flush(ctx), nil)
+		c.Remove("1")
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = b.GetMany(ctx, []string{"1"})
+		}()
+		<-s.loaded
+		Equal(t, b.Remove(ctx, "1"), nil)
+		s.release <- struct{}{}
+		<-done
+		Equal(t, c.Get("1"), optionext.None[int]())
+
+		guard := b.gens.Lock()
+		Equal(t, len(guard.T), 0)
+		guard.Unlock()
+		Equal(t, b.Close(ctx), nil)
+		_ = s.Store.Write(ctx, "1", 1)
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	syncext "github.com/go-playground/pkg/v5/sync"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+)
+
+// Memory is an in-memory Store, primarily for use in tests.
+type Memory[K comparable, V any] struct {
+	values syncext.RWMutex2[map[K]V]
+}
+
+// NewMemory creates a new in-memory Store for use.
+func NewMemory[K comparable, V any]() *Memory[K, V] {
+	return &Memory[K, V]{
+		values: syncext.NewRWMutex2(make(map[K]V)),
+	}
+}
+
+// Load returns the value for the key or None if it does not exist.
+func (m *Memory[K, V]) Load(_ context.Context, key K) (result optionext.Option[V], _ error) {
+	guard := m.values.RLock()
+	if value, found := guard.T[key]; found {
+		result = optionext.Some(value)
+	}
+	guard.RUnlock()
+	return
+}
+
+// LoadMany returns the values for all keys which exist.
+func (m *Memory[K, V]) LoadMany(_ context.Context, keys []K) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	guard := m.values.RLock()
+	for _, key := range keys {
+		if value, found := guard.T[key]; found {
+			values[key] = value
+		}
+	}
+	guard.RUnlock()
+	return values, nil
+}
+
+// Write creates or replaces the value for the key.
+func (m *Memory[K, V]) Write(_ context.Context, key K, value V) error {
+	guard := m.values.Lock()
+	guard.T[key] = value
+	guard.Unlock()
+	return nil
+}
+
+// Delete removes the key, if not present is a noop.
+func (m *Memory[K, V]) Delete(_ context.Context, key K) error {
+	guard := m.values.Lock()
+	delete(guard.T, key)
+	guard.Unlock()
+	return nil
+}
+
+// Len returns the number of keys in the Store.
+func (m *Memory[K, V]) Len() (n int) {
+	guard := m.values.RLock()
+	n = len(guard.T)
+	guard.RUnlock()
+	return
+}
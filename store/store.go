@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"time"
+)
+
+// Store is a backing store, such as a database or remote service, which a Backed cache reads through and writes to.
+type Store[K comparable, V any] interface {
+	// Load returns the value for the key or None if it does not exist.
+	Load(ctx context.Context, key K) (optionext.Option[V], error)
+
+	// LoadMany returns the values for all keys which exist.
+	LoadMany(ctx context.Context, keys []K) (map[K]V, error)
+
+	// Write creates or replaces the value for the key.
+	Write(ctx context.Context, key K, value V) error
+
+	// Delete removes the key, if not present is a noop.
+	Delete(ctx context.Context, key K) error
+}
+
+// Cache is the set of functions a Backed cache requires, satisfied by the lru and lfu ThreadSafeCache,
+// lru.ConcurrentCache and lfu.ShardedCache.
+type Cache[K comparable, V any] interface {
+	Set(key K, value V)
+	Get(key K) optionext.Option[V]
+	Remove(key K)
+}
+
+// negativeCache is implemented by caches able to record keys known to be absent.
+type negativeCache[K comparable, V any] interface {
+	SetMissing(key K, maxAge time.Duration)
+	Lookup(key K) (optionext.Option[V], bool)
+}
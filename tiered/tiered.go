@@ -0,0 +1,230 @@
+package tiered
+
+import (
+	syncext "github.com/go-playground/pkg/v5/sync"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"sync/atomic"
+)
+
+// Tier is the minimal set of functions a cache must implement to be used as a tier.
+//
+// It is satisfied by the lru and lfu ThreadSafeCache, lru.ConcurrentCache and lfu.ShardedCache.
+type Tier[K comparable, V any] interface {
+	Set(key K, value V)
+	Get(key K) optionext.Option[V]
+	Remove(key K)
+	Clear()
+}
+
+type builder[K comparable, V any] struct {
+	tiered *Cache[K, V]
+}
+
+// New initializes a builder to create a two tier cache with a small, fast l1 in front of a larger, slower l2.
+func New[K comparable, V any](l1, l2 Tier[K, V]) *builder[K, V] {
+	return &builder[K, V]{
+		tiered: &Cache[K, V]{
+			l1:      l1,
+			l2:      l2,
+			promote: true,
+			gens:    syncext.NewMutex2(make(map[K]*generation)),
+		},
+	}
+}
+
+// NewDemoting initializes a builder the same as New, building l1 by calling the provided function with a demote
+// function to call from its OnEvict function for capacity evictions, writing the evicted entries into l2. For example:
+//
+//	t := tiered.NewDemoting(func(demote func(key string, value string)) tiered.Tier[string, string] {
+//		return lru.New[string, string](100).OnEvict(func(key string, value string, reason lru.Reason) {
+//			if reason == lru.Capacity {
+//				demote(key, value)
+//			}
+//		}).BuildThreadSafe()
+//	}, l2).Build()
+func NewDemoting[K comparable, V any](l1 func(demote func(key K, value V)) Tier[K, V], l2 Tier[K, V]) *builder[K, V] {
+	b := New[K, V](nil, l2)
+	b.tiered.l1 = l1(b.tiered.Demote)
+	return b
+}
+
+// Promote sets if entries found in l2 are copied into l1 upon a Get.
+//
+// Default is true.
+func (b *builder[K, V]) Promote(promote bool) *builder[K, V] {
+	b.tiered.promote = promote
+	return b
+}
+
+// Build finalizes configuration and returns the tiered cache for use.
+func (b *builder[K, V]) Build() (tiered *Cache[K, V]) {
+	tiered = b.tiered
+	b.tiered = nil
+	return
+}
+
+// TierStats represents a single tiers statistics as observed by the tiered cache.
+type TierStats struct {
+	// Hits is the number of gets found in the tier.
+	Hits uint
+
+	// Misses is the number of gets not found in the tier.
+	Misses uint
+}
+
+// Stats represents the tiered cache statistics.
+type Stats struct {
+	// L1 is the statistics for the first tier.
+	L1 TierStats
+
+	// L2 is the statistics for the second tier.
+	L2 TierStats
+
+	// Promotions is the number of entries copied from l2 into l1.
+	Promotions uint
+
+	// Demotions is the number of entries evicted from l1 written into l2.
+	Demotions uint
+}
+
+// generation counts the in-flight promotions of a key and is bumped by every Set and Remove of the key, so that a
+// promotion racing a write does not overwrite it with the value read from l2.
+type generation struct {
+	promotions int
+	n          uint64
+}
+
+// Cache composes two tiers where reads fall through l1 to l2, promoting l2 hits into l1, and l1 evictions can
+// optionally be demoted into l2.
+//
+// Cache is safe for concurrent use provided both tiers are.
+type Cache[K comparable, V any] struct {
+	l1, l2     Tier[K, V]
+	promote    bool
+	l1Hits     atomic.Uint64
+	l1Misses   atomic.Uint64
+	l2Hits     atomic.Uint64
+	l2Misses   atomic.Uint64
+	promotions atomic.Uint64
+	demotions  atomic.Uint64
+	gens       syncext.Mutex2[map[K]*generation]
+}
+
+// Set sets an item into l1, removing any existing entry in l2 so that a stale value cannot later be read from it.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.bump(key)
+	c.l1.Set(key, value)
+	c.l2.Remove(key)
+}
+
+// Get attempts to find an existing cache entry by key in l1 and then l2, promoting an l2 hit into l1 unless the key is
+// set or removed while reading l2.
+// It returns an Option you must check before using the underlying value.
+func (c *Cache[K, V]) Get(key K) (result optionext.Option[V]) {
+	if result = c.l1.Get(key); result.IsSome() {
+		c.l1Hits.Add(1)
+		return
+	}
+	c.l1Misses.Add(1)
+
+	if !c.promote {
+		if result = c.l2.Get(key); result.IsSome() {
+			c.l2Hits.Add(1)
+		} else {
+			c.l2Misses.Add(1)
+		}
+		return
+	}
+
+	gen := c.begin(key)
+	if result = c.l2.Get(key); result.IsSome() {
+		c.l2Hits.Add(1)
+	} else {
+		c.l2Misses.Add(1)
+	}
+	c.end(key, gen, result)
+	return
+}
+
+// begin registers a promotion of the key, returning the generation to end it with.
+func (c *Cache[K, V]) begin(key K) uint64 {
+	guard := c.gens.Lock()
+	defer guard.Unlock()
+	g, found := guard.T[key]
+	if !found {
+		g = new(generation)
+		guard.T[key] = g
+	}
+	g.promotions++
+	return g.n
+}
+
+// end ends a promotion of the key, setting the result read from l2 into l1 if found and the key has not been set or
+// removed since the promotion began.
+func (c *Cache[K, V]) end(key K, gen uint64, result optionext.Option[V]) {
+	guard := c.gens.Lock()
+	defer guard.Unlock()
+	g := guard.T[key]
+	if result.IsSome() && g.n == gen {
+		c.l1.Set(key, result.Unwrap())
+		c.promotions.Add(1)
+	}
+	if g.promotions--; g.promotions == 0 {
+		delete(guard.T, key)
+	}
+}
+
+// bump invalidates any promotion of the key in flight. Called before updating the tiers, a promotion ending before is
+// overwritten while one ending after is discarded.
+func (c *Cache[K, V]) bump(key K) {
+	guard := c.gens.Lock()
+	if g, found := guard.T[key]; found {
+		g.n++
+	}
+	guard.Unlock()
+}
+
+// Demote writes an entry evicted from l1 into l2.
+//
+// It is intended to be called from the l1 caches OnEvict function for capacity evictions, see NewDemoting which wires
+// it up.
+func (c *Cache[K, V]) Demote(key K, value V) {
+	c.l2.Set(key, value)
+	c.demotions.Add(1)
+}
+
+// Remove removes the item matching the provided key from both tiers, if not present is a noop.
+func (c *Cache[K, V]) Remove(key K) {
+	c.bump(key)
+	c.l1.Remove(key)
+	c.l2.Remove(key)
+}
+
+// Clear empties both tiers.
+func (c *Cache[K, V]) Clear() {
+	guard := c.gens.Lock()
+	for _, g := range guard.T {
+		g.n++
+	}
+	guard.Unlock()
+	c.l1.Clear()
+	c.l2.Clear()
+}
+
+// Stats returns the delta of Stats since last call to the Stats function.
+//
+// Each tiers own Stats remain available from the tier itself.
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		L1: TierStats{
+			Hits:   uint(c.l1Hits.Swap(0)),
+			Misses: uint(c.l1Misses.Swap(0)),
+		},
+		L2: TierStats{
+			Hits:   uint(c.l2Hits.Swap(0)),
+			Misses: uint(c.l2Misses.Swap(0)),
+		},
+		Promotions: uint(c.promotions.Swap(0)),
+		Demotions:  uint(c.demotions.Swap(0)),
+	}
+}
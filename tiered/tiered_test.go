@@ -0,0 +1,114 @@
+package tiered
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"testing"
+)
+
+// blocking wraps a Tier blocking Gets, once read, until released so writes can race them.
+type blocking[K comparable, V any] struct {
+	Tier[K, V]
+	read, release chan struct{}
+}
+
+func (b *blocking[K, V]) Get(key K) optionext.Option[V] {
+	result := b.Tier.Get(key)
+	b.read <- struct{}{}
+	<-b.release
+	return result
+}
+
+func TestTiered(t *testing.T) {
+	var l1 lru.ThreadSafeCache[string, int]
+	l2 := lfu.New[string, int](10).BuildThreadSafe()
+	c := NewDemoting(func(demote func(key string, value int)) Tier[string, int] {
+		l1 = lru.New[string, int](2).OnEvict(func(key string, value int, reason lru.Reason) {
+			if reason == lru.Capacity {
+				demote(key, value)
+			}
+		}).BuildThreadSafe()
+		return l1
+	}, l2).Build()
+
+	c.Set("1", 1)
+	c.Set("2", 2)
+	c.Set("3", 3) // demotes 1
+	Equal(t, l1.Get("1"), optionext.None[int]())
+	Equal(t, l2.Get("1"), optionext.Some(1))
+
+	Equal(t, c.Get("3"), optionext.Some(3))
+	Equal(t, c.Get("1"), optionext.Some(1)) // promotes 1, demoting 2
+	Equal(t, l1.Get("1"), optionext.Some(1))
+	Equal(t, c.Get("4"), optionext.None[int]())
+
+	// setting removes any stale value in l2
+	c.Set("2", 20)
+	Equal(t, l2.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("2"), optionext.Some(20))
+
+	stats := c.Stats()
+	Equal(t, stats.L1.Hits, uint(2))
+	Equal(t, stats.L1.Misses, uint(2))
+	Equal(t, stats.L2.Hits, uint(1))
+	Equal(t, stats.L2.Misses, uint(1))
+	Equal(t, stats.Promotions, uint(1))
+	Equal(t, stats.Demotions, uint(3))
+
+	c.Remove("1")
+	Equal(t, c.Get("1"), optionext.None[int]())
+
+	c.Set("5", 5)
+	c.Clear()
+	Equal(t, c.Get("2"), optionext.None[int]())
+	Equal(t, c.Get("3"), optionext.None[int]())
+	Equal(t, c.Get("5"), optionext.None[int]())
+}
+
+func TestTieredNoPromotion(t *testing.T) {
+	l1 := lru.New[string, int](2).BuildThreadSafe()
+	l2 := lru.New[string, int](10).BuildThreadSafe()
+	c := New[string, int](l1, l2).Promote(false).Build()
+
+	l2.Set("1", 1)
+	Equal(t, c.Get("1"), optionext.Some(1))
+	Equal(t, l1.Get("1"), optionext.None[int]())
+
+	stats := c.Stats()
+	Equal(t, stats.L2.Hits, uint(1))
+	Equal(t, stats.Promotions, uint(0))
+}
+
+func TestTieredStalePromotion(t *testing.T) {
+	l1 := lru.New[string, int](10).BuildThreadSafe()
+	l2 := &blocking[string, int]{Tier: lru.New[string, int](10).BuildThreadSafe(), read: make(chan struct{}), release: make(chan struct{})}
+	c := New[string, int](l1, l2).Build()
+
+	for _, write := range []func(){
+		func() { c.Set("1", 2) },
+		func() { c.Remove("1") },
+		func() { c.Clear() },
+	} {
+		l1.Clear()
+		l2.Tier.Set("1", 1)
+
+		// the promotion completes after the write so must not overwrite it.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.Get("1")
+		}()
+		<-l2.read
+		write()
+		l2.release <- struct{}{}
+		<-done
+		NotEqual(t, l1.Get("1"), optionext.Some(1))
+	}
+
+	guard := c.gens.Lock()
+	Equal(t, len(guard.T), 0)
+	guard.Unlock()
+	Equal(t, c.Stats().Promotions, uint(0))
+}
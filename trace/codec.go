@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrFormat is returned when reading a trace which is malformed.
+var ErrFormat = errors.New("trace: malformed trace")
+
+// magic prefixes Binary traces, allowing a Reader to detect the Format.
+const magic = "GPCTRACE"
+
+type encoder struct {
+	w      *bufio.Writer
+	format Format
+	last   int64
+	buf    []byte
+}
+
+func newEncoder(w *bufio.Writer, format Format) *encoder {
+	return &encoder{w: w, format: format, last: -1}
+}
+
+func (e *encoder) encode(rec Record) error {
+	micros := rec.Time.UnixMicro()
+	if e.format == Binary {
+		e.buf = e.buf[:0]
+		if e.last < 0 {
+			e.buf = append(e.buf, magic...)
+			e.buf = binary.AppendUvarint(e.buf, uint64(micros))
+		} else {
+			e.buf = binary.AppendUvarint(e.buf, uint64(max(micros-e.last, 0)))
+		}
+		e.last = max(micros, e.last)
+		e.buf = append(e.buf, byte(rec.Op))
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(rec.Key)))
+		e.buf = append(e.buf, rec.Key...)
+		_, err := e.w.Write(e.buf)
+		return err
+	}
+	e.buf = strconv.AppendInt(e.buf[:0], micros, 10)
+	e.buf = append(e.buf, ' ', byte(rec.Op), ' ')
+	if strings.ContainsAny(rec.Key, " \t\r\n\"") || rec.Key == "" {
+		e.buf = strconv.AppendQuote(e.buf, rec.Key)
+	} else {
+		e.buf = append(e.buf, rec.Key...)
+	}
+	e.buf = append(e.buf, '\n')
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// Reader reads the Records of a trace written by a Recorder, detecting its Format.
+type Reader struct {
+	r      *bufio.Reader
+	binary bool
+	last   int64
+	line   int
+}
+
+// NewReader returns a Reader for the trace.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	prefix, err := br.Peek(len(magic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	reader := &Reader{r: br, last: -1}
+	if string(prefix) == magic {
+		reader.binary = true
+		_, _ = br.Discard(len(magic))
+	}
+	return reader, nil
+}
+
+// Read returns the next Record, or io.EOF once the trace has been fully read.
+func (r *Reader) Read() (Record, error) {
+	if r.binary {
+		return r.readBinary()
+	}
+	return r.readText()
+}
+
+func (r *Reader) readBinary() (rec Record, err error) {
+	delta, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return rec, err
+	}
+	if r.last < 0 {
+		r.last = int64(delta)
+	} else {
+		r.last += int64(delta)
+	}
+	op, err := r.r.ReadByte()
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	if n > 1<<20 {
+		return rec, ErrFormat
+	}
+	key := make([]byte, n)
+	if _, err = io.ReadFull(r.r, key); err != nil {
+		return rec, unexpected(err)
+	}
+	return Record{Time: time.UnixMicro(r.last), Op: Op(op), Key: string(key)}, nil
+}
+
+func (r *Reader) readText() (rec Record, err error) {
+	for {
+		line, err := r.r.ReadString('\n')
+		if line == "" && err != nil {
+			return rec, err
+		}
+		r.line++
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		ts, rest, ok1 := strings.Cut(line, " ")
+		op, key, ok2 := strings.Cut(rest, " ")
+		micros, err := strconv.ParseInt(ts, 10, 64)
+		if !ok1 || !ok2 || len(op) != 1 || err != nil {
+			return rec, fmt.Errorf("%w: line %d", ErrFormat, r.line)
+		}
+		if strings.HasPrefix(key, `"`) {
+			if key, err = strconv.Unquote(key); err != nil {
+				return rec, fmt.Errorf("%w: line %d", ErrFormat, r.line)
+			}
+		}
+		return Record{Time: time.UnixMicro(micros), Op: Op(op[0]), Key: key}, nil
+	}
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
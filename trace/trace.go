@@ -0,0 +1,331 @@
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"hash/maphash"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the set of functions recorded, satisfied by the lru and lfu Cache and ThreadSafeCache along with
+// lru.ConcurrentCache and lfu.ShardedCache.
+type Cache[K comparable, V any] interface {
+	Set(key K, value V)
+	Get(key K) optionext.Option[V]
+	Remove(key K)
+}
+
+// Format is the encoding of recorded traces.
+type Format uint8
+
+const (
+	// Text records one access per line as the unix time in microseconds, the Op and the key separated by spaces, with
+	// keys containing whitespace or quotes being quoted.
+	Text Format = iota
+
+	// Binary records a magic header followed by each access as the uvarint microseconds since the previous access, the
+	// first being the unix time in microseconds, the Op byte and the uvarint length prefixed key.
+	Binary
+)
+
+// Op is a recorded cache operation.
+type Op byte
+
+const (
+	// Hit is a Get which found the key.
+	Hit Op = 'H'
+
+	// Miss is a Get which did not find the key.
+	Miss Op = 'M'
+
+	// Set is a Set of the key.
+	Set Op = 'S'
+
+	// Remove is a Remove of the key.
+	Remove Op = 'R'
+)
+
+// IsGet returns if the Op is a Get, either a Hit or Miss.
+func (op Op) IsGet() bool {
+	return op == Hit || op == Miss
+}
+
+// Record is a single recorded access.
+type Record struct {
+	// Time is when the access occurred, with microsecond precision.
+	Time time.Time
+
+	// Op is the operation performed.
+	Op Op
+
+	// Key is the accessed key, or its hash in hex when recorded with HashKeys.
+	Key string
+}
+
+// Stats represents the recorders statistics.
+type Stats struct {
+	// Recorded is the number of accesses written.
+	Recorded uint64
+
+	// Dropped is the number of sampled accesses discarded because the buffer was full or the recorder closed.
+	Dropped uint64
+}
+
+type builder[K comparable, V any] struct {
+	recorder *Recorder[K, V]
+}
+
+// New initializes a builder to create a Recorder wrapping the cache and writing each Get, Set and Remove to w.
+func New[K comparable, V any](cache Cache[K, V], w io.Writer) *builder[K, V] {
+	return &builder[K, V]{
+		recorder: &Recorder[K, V]{
+			cache:    cache,
+			w:        bufio.NewWriterSize(w, 64<<10),
+			seed:     maphash.MakeSeed(),
+			key:      func(key K) string { return fmt.Sprint(key) },
+			onError:  func(error) {},
+			interval: time.Second,
+			size:     4096,
+		},
+	}
+}
+
+// Format sets the encoding of the trace.
+//
+// Default is Text.
+func (b *builder[K, V]) Format(format Format) *builder[K, V] {
+	b.recorder.format = format
+	return b
+}
+
+// Key sets the function converting keys to their recorded form.
+//
+// Default uses fmt.Sprint.
+func (b *builder[K, V]) Key(fn func(key K) string) *builder[K, V] {
+	b.recorder.key = fn
+	return b
+}
+
+// HashKeys records a 64 bit hash of each key, in hex, rather than the key itself for privacy. Hashes are consistent
+// within a single Recorder only unless Recorders share a Seed, and never across processes.
+//
+// Default records the keys.
+func (b *builder[K, V]) HashKeys() *builder[K, V] {
+	b.recorder.hash = true
+	return b
+}
+
+// Seed sets the seed used to hash keys for HashKeys and Sample, so that Recorders sharing it within a process record
+// the same hashes and sample the same keys. Being a maphash.Seed it cannot be persisted or shared across processes.
+//
+// Default is a random seed per Recorder.
+func (b *builder[K, V]) Seed(seed maphash.Seed) *builder[K, V] {
+	b.recorder.seed = seed
+	return b
+}
+
+// Sample sets the fraction of keys, between 0 and 1, whose accesses are recorded. Sampling is by key hash, so every
+// access to a sampled key is recorded, preserving the reuse distances needed for offline analysis.
+//
+// Default is 1, recording every access.
+func (b *builder[K, V]) Sample(rate float64) *builder[K, V] {
+	if rate <= 0 || rate > 1 {
+		panic("Sample rate must be greater than 0 and at most 1")
+	}
+	b.recorder.threshold = uint64(rate * math.MaxUint64)
+	if rate == 1 {
+		b.recorder.threshold = 0
+	}
+	return b
+}
+
+// Buffer sets the number of accesses buffered for the background writer before further accesses are dropped.
+//
+// Default is 4096.
+func (b *builder[K, V]) Buffer(size int) *builder[K, V] {
+	if size <= 0 {
+		panic("Buffer must be a positive value")
+	}
+	b.recorder.size = size
+	return b
+}
+
+// FlushInterval sets how often buffered writes are flushed to the underlying writer.
+//
+// Default is one second.
+func (b *builder[K, V]) FlushInterval(interval time.Duration) *builder[K, V] {
+	if interval <= 0 {
+		panic("FlushInterval must be a positive value")
+	}
+	b.recorder.interval = interval
+	return b
+}
+
+// OnError sets a function to be called with the first error writing the trace, after which recording stops.
+//
+// Default ignores errors.
+func (b *builder[K, V]) OnError(fn func(err error)) *builder[K, V] {
+	b.recorder.onError = fn
+	return b
+}
+
+// Build finalizes configuration and returns the Recorder for use, starting the background writer.
+func (b *builder[K, V]) Build() (recorder *Recorder[K, V]) {
+	recorder = b.recorder
+	b.recorder = nil
+	recorder.records = make(chan Record, recorder.size)
+	recorder.done = make(chan struct{})
+	recorder.stopped = make(chan struct{})
+	go recorder.run()
+	return
+}
+
+// Recorder wraps a cache recording each Get, Set and Remove to a writer.
+//
+// Accesses are sent to a buffer drained by a background writer so the caller never blocks on I/O, accesses arriving
+// while the buffer is full are dropped and counted in Stats.
+type Recorder[K comparable, V any] struct {
+	cache     Cache[K, V]
+	w         *bufio.Writer
+	format    Format
+	seed      maphash.Seed
+	key       func(key K) string
+	hash      bool
+	threshold uint64
+	onError   func(error)
+	interval  time.Duration
+	size      int
+
+	records   chan Record
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	closed    atomic.Bool
+	recorded  atomic.Uint64
+	dropped   atomic.Uint64
+	err       error
+}
+
+// Set sets the item into the cache, recording the access.
+func (r *Recorder[K, V]) Set(key K, value V) {
+	r.cache.Set(key, value)
+	r.record(Set, key)
+}
+
+// Get gets the item from the cache, recording the access as a Hit or Miss.
+func (r *Recorder[K, V]) Get(key K) (result optionext.Option[V]) {
+	result = r.cache.Get(key)
+	if result.IsSome() {
+		r.record(Hit, key)
+	} else {
+		r.record(Miss, key)
+	}
+	return
+}
+
+// Remove removes the item from the cache, recording the access.
+func (r *Recorder[K, V]) Remove(key K) {
+	r.cache.Remove(key)
+	r.record(Remove, key)
+}
+
+// Cache returns the wrapped cache for access to functions which are not recorded.
+func (r *Recorder[K, V]) Cache() Cache[K, V] {
+	return r.cache
+}
+
+func (r *Recorder[K, V]) record(op Op, key K) {
+	var h uint64
+	if r.hash || r.threshold > 0 {
+		h = maphash.Comparable(r.seed, key)
+		if r.threshold > 0 && h > r.threshold {
+			return
+		}
+	}
+	if r.closed.Load() {
+		r.dropped.Add(1)
+		return
+	}
+	rec := Record{Time: time.Now(), Op: op}
+	if r.hash {
+		rec.Key = strconv.FormatUint(h, 16)
+	} else {
+		rec.Key = r.key(key)
+	}
+	select {
+	case r.records <- rec:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+func (r *Recorder[K, V]) run() {
+	defer close(r.stopped)
+
+	enc := newEncoder(r.w, r.format)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	write := func(rec Record) {
+		if r.err != nil {
+			r.dropped.Add(1)
+			return
+		}
+		if r.err = enc.encode(rec); r.err != nil {
+			r.onError(r.err)
+			return
+		}
+		r.recorded.Add(1)
+	}
+	flush := func() {
+		if r.err == nil {
+			if r.err = r.w.Flush(); r.err != nil {
+				r.onError(r.err)
+			}
+		}
+	}
+
+	for {
+		select {
+		case rec := <-r.records:
+			write(rec)
+		case <-ticker.C:
+			flush()
+		case <-r.done:
+			for {
+				select {
+				case rec := <-r.records:
+					write(rec)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close stops recording, writing any buffered accesses, and returns the first write error if any. It does not close
+// the underlying writer.
+func (r *Recorder[K, V]) Close() error {
+	r.closeOnce.Do(func() {
+		r.closed.Store(true)
+		close(r.done)
+	})
+	<-r.stopped
+	return r.err
+}
+
+// Stats returns the recorders statistics.
+func (r *Recorder[K, V]) Stats() Stats {
+	return Stats{
+		Recorded: r.recorded.Load(),
+		Dropped:  r.dropped.Load(),
+	}
+}
@@ -0,0 +1,143 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/go-playground/assert/v2"
+	"github.com/go-playground/cache/lfu"
+	"github.com/go-playground/cache/lru"
+	"hash/maphash"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, r io.Reader) []Record {
+	reader, err := NewReader(r)
+	Equal(t, err, nil)
+	var records []Record
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		Equal(t, err, nil)
+		records = append(records, rec)
+	}
+}
+
+func ops(records []Record) string {
+	var sb strings.Builder
+	for _, rec := range records {
+		sb.WriteString(string(rec.Op) + rec.Key + ",")
+	}
+	return sb.String()
+}
+
+func TestRecorder(t *testing.T) {
+	for _, format := range []Format{Text, Binary} {
+		var buf bytes.Buffer
+		cache := lru.New[string, int](10).BuildThreadSafe()
+		r := New[string, int](cache, &buf).Format(format).Build()
+
+		r.Set("a", 1)
+		Equal(t, r.Get("a").Unwrap(), 1)
+		Equal(t, r.Get("b").IsNone(), true)
+		r.Remove("a")
+		r.Set("with space", 2)
+		Equal(t, r.Close(), nil)
+		Equal(t, r.Close(), nil)
+
+		records := readAll(t, &buf)
+		Equal(t, ops(records), "Sa,Ha,Mb,Ra,Swith space,")
+		Equal(t, records[0].Op.IsGet(), false)
+		Equal(t, records[1].Op.IsGet(), true)
+		Equal(t, time.Since(records[0].Time) < time.Minute, true)
+		Equal(t, r.Stats(), Stats{Recorded: 5})
+
+		// recording stops once closed
+		r.Set("c", 3)
+		Equal(t, r.Stats().Dropped, uint64(1))
+	}
+}
+
+func TestRecorderHashAndSample(t *testing.T) {
+	var buf bytes.Buffer
+	cache := lfu.New[int, int](1000).Build()
+	r := New[int, int](cache, &buf).HashKeys().Sample(0.25).Buffer(10_000).Build()
+	for i := 0; i < 1000; i++ {
+		r.Set(i, i)
+		r.Get(i)
+	}
+	Equal(t, r.Close(), nil)
+
+	records := readAll(t, &buf)
+	Equal(t, len(records) > 300 && len(records) < 700, true)
+	Equal(t, len(records)%2, 0)
+	seen := make(map[string]int)
+	for _, rec := range records {
+		_, err := strconv.ParseUint(rec.Key, 16, 64)
+		Equal(t, err, nil)
+		seen[rec.Key]++
+	}
+	// every access of a sampled key is recorded
+	for _, count := range seen {
+		Equal(t, count, 2)
+	}
+}
+
+func TestRecorderSeed(t *testing.T) {
+	seed := maphash.MakeSeed()
+	var keys [2]string
+	for i := range keys {
+		var buf bytes.Buffer
+		r := New[int, int](lfu.New[int, int](10).Build(), &buf).HashKeys().Seed(seed).Build()
+		r.Set(1, 1)
+		Equal(t, r.Close(), nil)
+		records := readAll(t, &buf)
+		Equal(t, len(records), 1)
+		keys[i] = records[0].Key
+	}
+	Equal(t, keys[0], keys[1])
+}
+
+func TestRecorderDrops(t *testing.T) {
+	pr, pw := io.Pipe()
+	cache := lru.New[int, int](10).BuildThreadSafe()
+	r := New[int, int](cache, pw).Buffer(1).Build()
+	for i := 0; i < 100_000; i++ {
+		r.Set(i, i)
+	}
+	Equal(t, r.Stats().Dropped > 0, true)
+	go func() { _, _ = io.Copy(io.Discard, pr) }()
+	Equal(t, r.Close(), nil)
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestRecorderError(t *testing.T) {
+	var reported error
+	cache := lru.New[int, int](10).BuildThreadSafe()
+	r := New[int, int](cache, errWriter{}).OnError(func(err error) { reported = err }).Build()
+	r.Set(1, 1)
+	Equal(t, r.Close(), io.ErrClosedPipe)
+	Equal(t, reported, io.ErrClosedPipe)
+}
+
+func TestReaderMalformed(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("x S a\n"))
+	Equal(t, err, nil)
+	_, err = reader.Read()
+	Equal(t, errors.Is(err, ErrFormat), true)
+
+	reader, err = NewReader(strings.NewReader(magic + "\x01"))
+	Equal(t, err, nil)
+	_, err = reader.Read()
+	Equal(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,203 @@
+// Package weakcache provides a cache of pointer values which keeps a small strong LRU core of the most recently used
+// values and holds the remainder using weak pointers, allowing the garbage collector to reclaim them once nothing else
+// references them while still serving them for as long as they are alive.
+package weakcache
+
+import (
+	"github.com/go-playground/cache/lru"
+	optionext "github.com/go-playground/pkg/v5/values/option"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+type builder[K comparable, V any] struct {
+	cache *Cache[K, V]
+}
+
+// New initializes a builder to create a weak valued cache, the capacity being the number of most recently used values
+// held strongly. Values evicted from the strong core are demoted to weak pointers.
+func New[K comparable, V any](capacity int) *builder[K, V] {
+	c := &Cache[K, V]{
+		weak: make(map[K]weakEntry[V]),
+	}
+	c.strong = lru.New[K, *V](capacity).OnEvict(c.evicted).Build()
+	return &builder[K, V]{cache: c}
+}
+
+// Build finalizes configuration and returns the weak valued cache for use.
+func (b *builder[K, V]) Build() (cache *Cache[K, V]) {
+	cache = b.cache
+	b.cache = nil
+	return
+}
+
+// Stats represents the cache statistics.
+type Stats struct {
+	// Capacity is the number of values held strongly.
+	Capacity int
+
+	// Len is the number of entries, strong and weak. Weak entries whose values have been collected but not yet
+	// cleaned up are included.
+	Len int
+
+	// Weak is the number of entries held by weak pointer.
+	Weak int
+
+	// Hits is the number of gets found, strongly or weakly. Strong hits are Hits less WeakHits.
+	Hits uint
+
+	// WeakHits is the number of gets found held by weak pointer and still alive, which are promoted back into the
+	// strong core.
+	WeakHits uint
+
+	// Misses is the number of gets not found, including those whose value was collected.
+	Misses uint
+
+	// Gets is the number of gets.
+	Gets uint
+
+	// Sets is the number of sets.
+	Sets uint
+
+	// Removals is the number of entries removed via Remove.
+	Removals uint
+
+	// Demotions is the number of values evicted from the strong core to be held by weak pointer.
+	Demotions uint
+
+	// Collected is the number of weak entries cleaned up after their value was reclaimed by the garbage collector.
+	Collected uint
+}
+
+type weakEntry[V any] struct {
+	ptr     weak.Pointer[V]
+	cleanup runtime.Cleanup
+}
+
+// collectedArg is passed to the cleanup of a demoted value, the pointer distinguishing it from any later entry of
+// the same key.
+type collectedArg[K comparable, V any] struct {
+	key K
+	ptr weak.Pointer[V]
+}
+
+// Cache is a weak valued cache. Values are pointers so they can be held weakly once demoted from the strong core.
+//
+// Cache is safe for concurrent use, being guarded by a mutex, as cleanups of collected values run on their own
+// goroutine.
+type Cache[K comparable, V any] struct {
+	m      sync.Mutex
+	strong *lru.Cache[K, *V]
+	weak   map[K]weakEntry[V]
+	stats  Stats
+}
+
+// evicted is the OnEvict function of the strong core, demoting values evicted to remain within capacity.
+func (cache *Cache[K, V]) evicted(key K, value *V, reason lru.Reason) {
+	switch reason {
+	case lru.Capacity:
+		ptr := weak.Make(value)
+		cache.weak[key] = weakEntry[V]{
+			ptr:     ptr,
+			cleanup: runtime.AddCleanup(value, cache.collected, collectedArg[K, V]{key: key, ptr: ptr}),
+		}
+		cache.stats.Demotions++
+	case lru.Removed:
+		cache.stats.Removals++
+	}
+}
+
+// collected removes the weak entry of a value reclaimed by the garbage collector, if still present.
+func (cache *Cache[K, V]) collected(arg collectedArg[K, V]) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	if e, found := cache.weak[arg.key]; found && e.ptr == arg.ptr {
+		delete(cache.weak, arg.key)
+		cache.stats.Collected++
+	}
+}
+
+// removeWeak removes the weak entry of the key, if present, returning it.
+func (cache *Cache[K, V]) removeWeak(key K) (e weakEntry[V], found bool) {
+	if e, found = cache.weak[key]; found {
+		e.cleanup.Stop()
+		delete(cache.weak, key)
+	}
+	return
+}
+
+// Set sets an item into the cache, held strongly as the most recently used. It will replace the current entry if
+// there is one.
+//
+// The value must not be nil.
+func (cache *Cache[K, V]) Set(key K, value *V) {
+	if value == nil {
+		panic("Set value must not be nil")
+	}
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	cache.stats.Sets++
+	cache.removeWeak(key)
+	cache.strong.Set(key, value)
+}
+
+// Get attempts to find an existing cache entry by key. A value held by weak pointer and still alive is promoted back
+// into the strong core.
+func (cache *Cache[K, V]) Get(key K) (result optionext.Option[*V]) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	cache.stats.Gets++
+
+	if result = cache.strong.Get(key); result.IsSome() {
+		cache.stats.Hits++
+		return
+	}
+	if e, found := cache.removeWeak(key); found {
+		if value := e.ptr.Value(); value != nil {
+			cache.stats.Hits++
+			cache.stats.WeakHits++
+			cache.strong.Set(key, value)
+			return optionext.Some(value)
+		}
+		// collected but its cleanup has yet to run.
+		cache.stats.Collected++
+	}
+	cache.stats.Misses++
+	return
+}
+
+// Remove removes the item matching the provided key from the cache, if not present is a noop.
+func (cache *Cache[K, V]) Remove(key K) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	cache.strong.Remove(key)
+	if _, found := cache.removeWeak(key); found {
+		cache.stats.Removals++
+	}
+}
+
+// Clear empties the cache, resetting Stats.
+func (cache *Cache[K, V]) Clear() {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	cache.strong.Clear()
+	for _, e := range cache.weak {
+		e.cleanup.Stop()
+	}
+	clear(cache.weak)
+	cache.stats = Stats{}
+}
+
+// Stats returns the delta of Stats since last call to the Stats function.
+func (cache *Cache[K, V]) Stats() (stats Stats) {
+	cache.m.Lock()
+	defer cache.m.Unlock()
+	strong := cache.strong.Stats()
+	stats = cache.stats
+	stats.Capacity = strong.Capacity
+	stats.Len = strong.Len + len(cache.weak)
+	stats.Weak = len(cache.weak)
+	cache.stats = Stats{}
+	return
+}
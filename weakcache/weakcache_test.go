@@ -0,0 +1,118 @@
+package weakcache
+
+import (
+	. "github.com/go-playground/assert/v2"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// value is large enough to not be tiny allocated, which would delay its collection and cleanup.
+type value struct {
+	n   int
+	pad [64]byte
+}
+
+func TestWeakCache(t *testing.T) {
+	c := New[string, value](2).Build()
+	a, b, d := &value{n: 1}, &value{n: 2}, &value{n: 3}
+	c.Set("a", a)
+	c.Set("b", b)
+	c.Set("d", d) // demotes a
+
+	Equal(t, c.Get("b").Unwrap(), b)
+	Equal(t, c.Get("a").Unwrap(), a) // promoted, demoting d
+	Equal(t, c.Get("d").Unwrap(), d) // promoted, demoting b
+	Equal(t, c.Get("z").IsNone(), true)
+
+	stats := c.Stats()
+	Equal(t, stats.Capacity, 2)
+	Equal(t, stats.Len, 3)
+	Equal(t, stats.Weak, 1)
+	Equal(t, stats.Gets, uint(4))
+	Equal(t, stats.Sets, uint(3))
+	Equal(t, stats.Hits, uint(3))
+	Equal(t, stats.WeakHits, uint(2))
+	Equal(t, stats.Misses, uint(1))
+	Equal(t, stats.Demotions, uint(3))
+
+	// replacing a weak entry removes it.
+	b2 := &value{n: 20}
+	c.Set("b", b2)
+	Equal(t, c.Get("b").Unwrap(), b2)
+
+	c.Remove("a")
+	c.Remove("missing")
+	Equal(t, c.Get("a").IsNone(), true)
+
+	c.Clear()
+	Equal(t, c.Get("b").IsNone(), true)
+	Equal(t, c.Get("d").IsNone(), true)
+	stats = c.Stats()
+	Equal(t, stats.Len, 0)
+	Equal(t, stats.Weak, 0)
+	Equal(t, stats.Removals, uint(0))
+	Equal(t, stats.Sets, uint(0))
+	runtime.KeepAlive(b)
+	runtime.KeepAlive(d)
+}
+
+func TestWeakCacheCollected(t *testing.T) {
+	c := New[int, value](1).Build()
+	c.Set(1, &value{n: 1})
+	c.Set(2, &value{n: 2}) // demotes 1, now only weakly referenced
+
+	var weak int
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		c.m.Lock()
+		weak = len(c.weak)
+		c.m.Unlock()
+		if weak == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	Equal(t, weak, 0)
+	Equal(t, c.Get(1).IsNone(), true)
+	Equal(t, c.Get(2).Unwrap().n, 2)
+
+	stats := c.Stats()
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Collected, uint(1))
+	Equal(t, stats.Misses, uint(1))
+	Equal(t, stats.Hits, uint(1))
+	Equal(t, stats.WeakHits, uint(0))
+}
+
+func TestWeakCacheCollectedBeforeCleanup(t *testing.T) {
+	c := New[int, value](1).Build()
+	c.Set(1, &value{n: 1})
+	c.Set(2, &value{n: 2})
+
+	// stop the cleanup so the collected value is only found by Get.
+	c.m.Lock()
+	c.weak[1].cleanup.Stop()
+	c.m.Unlock()
+
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		c.m.Lock()
+		collected := c.weak[1].ptr.Value() == nil
+		c.m.Unlock()
+		if collected {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	Equal(t, c.Get(1).IsNone(), true)
+
+	stats := c.Stats()
+	Equal(t, stats.Len, 1)
+	Equal(t, stats.Collected, uint(1))
+	Equal(t, stats.Misses, uint(1))
+}
+
+func TestWeakCacheNilValue(t *testing.T) {
+	PanicMatches(t, func() { New[int, value](1).Build().Set(1, nil) }, "Set value must not be nil")
+}